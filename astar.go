@@ -0,0 +1,159 @@
+package ricochet
+
+import "container/heap"
+
+// SolveAStar returns the same number of moves as `Solve` but uses an A* search,
+// which explores far fewer states on deep puzzles.
+func (s *State) SolveAStar(tok Token) []Move {
+	return s.solveWith(SolveOptions{Algorithm: AlgorithmAStar}, tok)
+}
+
+// lowerBounds returns, for every cell on the board, the minimum number of moves
+// a robot needs to get from that cell to `pos`, or -1 if it can never get
+// there. Other robots are ignored, except that a robot is allowed to stop
+// anywhere along a slide: another robot might always be in the right place to
+// block it, so the bound never overestimates.
+func (b *Board) lowerBounds(pos Position) []int {
+	bounds := make([]int, b.size*b.size)
+	for i := range bounds {
+		bounds[i] = -1
+	}
+	bounds[b.index(pos)] = 0
+
+	queue := []Position{pos}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		// Anything in line of sight of `p` can get there in one more move.
+		n := bounds[b.index(p)] + 1
+		for _, d := range allDirections {
+			for q := p; b.canSlide(q, d); {
+				q = q.Next(d)
+				if i := b.index(q); bounds[i] < 0 {
+					bounds[i] = n
+					queue = append(queue, q)
+				}
+			}
+		}
+	}
+
+	return bounds
+}
+
+// lowerBound returns the minimum number of moves needed to claim `tok` from
+// this state, or -1 if it can't be claimed.
+func (s *State) lowerBound(tok Token, bounds []int) int {
+	best := -1
+	for i, r := range s.robots {
+		if !r.Claims(tok) {
+			continue
+		}
+		if n := bounds[s.board.index(s.pos[i])]; n >= 0 && (best < 0 || n < best) {
+			best = n
+		}
+	}
+	return best
+}
+
+// astar does an A* search for a solution. Since moving a robot changes its
+// lower bound by at most one, the first solution taken off the queue is a
+// shortest one.
+func (sr *search) astar() ([]Move, error) {
+	var queue astarQueue
+	heap.Push(&queue, &astarNode{node{state: sr.start}, sr.bound, 0})
+	best := map[stateKey]int{sr.start.key(sr.target): 0}
+	size := nodeBytes(len(sr.start.robots))
+	sr.memory += size
+	sr.deepen(sr.bound)
+
+	var ml []Move
+	cut := false // whether any state was beyond the maximum depth
+	for queue.Len() > 0 {
+		n := heap.Pop(&queue).(*astarNode)
+		qs := n.state
+		if n.depth > best[qs.key(sr.target)] {
+			continue // we've since found a shorter way here
+		}
+		if n.f > sr.bound {
+			sr.deepen(n.f)
+		}
+		if qs.Solved(sr.tok) {
+			return n.path(), nil
+		}
+		if err := sr.expand(); err != nil {
+			return nil, err
+		}
+
+		ml = qs.moves(ml[:0])
+		for _, m := range ml {
+			newState := qs.after(m)
+			h := sr.lowerBound(newState)
+			if h < 0 {
+				continue
+			}
+
+			g := n.depth + 1
+			if sr.deeper(g + h) {
+				cut = true
+				continue
+			}
+
+			hash := newState.key(sr.target)
+			if b, ok := best[hash]; ok && b <= g {
+				sr.stats.StatesDeduplicated++
+				continue
+			}
+			best[hash] = g
+
+			heap.Push(&queue, &astarNode{
+				node{newState, &n.node, m, g}, g + h, queue.pushed})
+			sr.memory += size
+		}
+		sr.frontier(queue.Len())
+	}
+
+	if cut {
+		sr.bound = sr.opts.MaxDepth + 1
+		return nil, ErrDepthExceeded
+	}
+	return nil, nil
+}
+
+type astarNode struct {
+	node
+	f     int // moves so far plus lower bound on moves to go
+	order int // tie-breaker: states found first are taken first
+}
+
+// astarQueue is a priority queue of states ordered by lowest `f`, preferring
+// deeper states when there's a tie.
+type astarQueue struct {
+	nodes  []*astarNode
+	pushed int
+}
+
+func (q astarQueue) Len() int { return len(q.nodes) }
+
+func (q astarQueue) Less(i, j int) bool {
+	a, b := q.nodes[i], q.nodes[j]
+	if a.f != b.f {
+		return a.f < b.f
+	}
+	if a.depth != b.depth {
+		return a.depth > b.depth
+	}
+	return a.order < b.order
+}
+func (q astarQueue) Swap(i, j int) { q.nodes[i], q.nodes[j] = q.nodes[j], q.nodes[i] }
+
+func (q *astarQueue) Push(x interface{}) {
+	q.nodes = append(q.nodes, x.(*astarNode))
+	q.pushed++
+}
+
+func (q *astarQueue) Pop() interface{} {
+	n := q.nodes[len(q.nodes)-1]
+	q.nodes = q.nodes[:len(q.nodes)-1]
+	return n
+}
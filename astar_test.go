@@ -0,0 +1,70 @@
+package ricochet
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestBoardLowerBounds(t *testing.T) {
+	b, _ := NewBoard(5)
+	b.AddWall(Position{2, 2}, DirectionEast)
+	b.SetOOB(Position{0, 4})
+	bounds := b.lowerBounds(Position{2, 2})
+
+	exp := []int{
+		2, 2, 1, 2, 2,
+		2, 2, 1, 2, 2,
+		1, 1, 0, 3, 3, // the wall means going round
+		2, 2, 1, 2, 2,
+		-1, 2, 1, 2, 2,
+	}
+	for i := range exp {
+		if bounds[i] != exp[i] {
+			t.Errorf("expected bound %d at %v, got %d",
+				exp[i], Position{i % 5, i / 5}, bounds[i])
+		}
+	}
+}
+
+func TestStateSolveAStar(t *testing.T) {
+	testSolve(t, (*State).SolveAStar)
+}
+
+func TestStateSolveAStarExpandsFewer(t *testing.T) {
+	_, s := readTestBoard(t)
+
+	for _, tok := range []Token{
+		{ShapeCircle, ColourBlue},
+		{ShapeDiamond, ColourBlue},
+		{ShapeHexagon, ColourYellow},
+	} {
+		bfs := solveExpanded(t, s, tok, AlgorithmBFS)
+		astar := solveExpanded(t, s, tok, AlgorithmAStar)
+		if astar >= bfs {
+			t.Errorf("expected A* to expand fewer than %d states for %v, got %d",
+				bfs, tok, astar)
+		}
+	}
+}
+
+func BenchmarkSolve(b *testing.B) {
+	benchmarkSolve(b, (*State).Solve)
+}
+
+func BenchmarkSolveAStar(b *testing.B) {
+	benchmarkSolve(b, (*State).SolveAStar)
+}
+
+func benchmarkSolve(b *testing.B, solve func(*State, Token) []Move) {
+	_, s, err := ReadBoard(bufio.NewReader(strings.NewReader(testBoard)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	tok := Token{ShapeHexagon, ColourBlue}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		solve(s, tok)
+	}
+}
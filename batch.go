@@ -0,0 +1,93 @@
+package ricochet
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// tokens returns every token with a sink on this board, by shape and then
+// colour, so the vortex comes last.
+func (b *Board) tokens() []Token {
+	tokens := make(tokenSlice, 0, len(b.sinks))
+	for tok := range b.sinks {
+		tokens = append(tokens, tok)
+	}
+	sort.Sort(tokens)
+	return tokens
+}
+
+type tokenSlice []Token
+
+func (ts tokenSlice) Len() int      { return len(ts) }
+func (ts tokenSlice) Swap(i, j int) { ts[i], ts[j] = ts[j], ts[i] }
+
+func (ts tokenSlice) Less(i, j int) bool {
+	if ts[i].Shape != ts[j].Shape {
+		return ts[i].Shape < ts[j].Shape
+	}
+	return ts[i].Colour < ts[j].Colour
+}
+
+// SolveAll solves for each of `tokens` from `start`, or for every token on the
+// board if `tokens` is nil. Tokens are solved at once by a pool of
+// `opts.Workers` goroutines, or one for each CPU if that's 0; a parallel
+// breadth-first search then uses one worker per token. The board mustn't be
+// changed until it returns.
+//
+// Every token gets a solution, even if the context is done before it's
+// solved, in which case it only holds what was proven so far. The error is
+// the first error solving for any token, in the order of `tokens`.
+func (b *Board) SolveAll(ctx context.Context, start *State, tokens []Token, opts SolveOptions) (map[Token]*Solution, error) {
+	if start.board != b {
+		return nil, errors.New("state is for a different board")
+	}
+	if tokens == nil {
+		tokens = b.tokens()
+	}
+
+	workers := opts.Workers
+	opts.Workers = 1
+	sv := NewSolver(opts)
+	solutions := make([]*Solution, len(tokens))
+	errs := make([]error, len(tokens))
+	forEach(len(tokens), workers, func(i int) {
+		solutions[i], errs[i] = sv.Solve(ctx, start, tokens[i])
+	})
+
+	all := make(map[Token]*Solution, len(tokens))
+	var err error
+	for i, tok := range tokens {
+		all[tok] = solutions[i]
+		if err == nil {
+			err = errs[i]
+		}
+	}
+	return all, err
+}
+
+// forEach calls `f` with each number from 0 to n-1, from a pool of `workers`
+// goroutines, or one for each CPU if that's 0.
+func forEach(n, workers int, f func(i int)) {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				f(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
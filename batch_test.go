@@ -0,0 +1,79 @@
+package ricochet
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBoardSolveAll(t *testing.T) {
+	b, s := readTestBoard(t)
+	for _, workers := range []int{0, 1, 4} {
+		opts := SolveOptions{Algorithm: AlgorithmAStar, Workers: workers}
+		all, err := b.SolveAll(context.Background(), s, nil, opts)
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if len(all) != len(solveTests) {
+			t.Errorf("expected %d solutions, got %d", len(solveTests), len(all))
+		}
+		for _, test := range solveTests {
+			sol, ok := all[test.Token]
+			if !ok {
+				t.Errorf("expected a solution for %v", test.Token)
+				continue
+			}
+			if len(sol.Moves) != test.Moves {
+				t.Errorf("expected %v in %d moves, got %d", test.Token,
+					test.Moves, len(sol.Moves))
+			}
+			if err := b.Verify(s, test.Token, sol.Moves); err != nil {
+				t.Errorf("%v: %v", test.Token, err)
+			}
+			if sol.Stats.NodesExpanded == 0 {
+				t.Errorf("%v: expected stats", test.Token)
+			}
+		}
+	}
+
+	// Tokens that aren't on the board have no solution.
+	tokens := []Token{{ShapeTriangle, ColourBlue}, TokenVortex}
+	all, err := b.SolveAll(context.Background(), s, tokens, SolveOptions{})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if len(all) != 2 || len(all[tokens[0]].Moves) != 1 || all[TokenVortex].Moves != nil {
+		t.Errorf("expected 1 move and no solution, got %v", all)
+	}
+
+	other, _ := NewBoard(16)
+	if _, err := other.SolveAll(context.Background(), s, nil, SolveOptions{}); err == nil {
+		t.Errorf("expected error solving a state for a different board")
+	}
+}
+
+func TestBoardSolveAllDeadline(t *testing.T) {
+	b, s := readTestBoard(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	all, err := b.SolveAll(ctx, s, nil, SolveOptions{Workers: 2})
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if elapsed := time.Since(started); elapsed > time.Second {
+		t.Errorf("expected to stop at the deadline, took %v", elapsed)
+	}
+	if len(all) != len(solveTests) {
+		t.Errorf("expected %d solutions, got %d", len(solveTests), len(all))
+	}
+	for _, test := range solveTests {
+		if sol := all[test.Token]; sol == nil {
+			t.Errorf("expected a solution for %v", test.Token)
+		} else if sol.Moves != nil && len(sol.Moves) != test.Moves {
+			t.Errorf("expected %v in %d moves, got %d", test.Token, test.Moves,
+				len(sol.Moves))
+		}
+	}
+}
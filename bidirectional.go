@@ -0,0 +1,230 @@
+package ricochet
+
+// ReverseMoves returns every move that could have left the robot in `pos`
+// where it is, given where the other robots are. A robot can only have
+// stopped in `pos` moving in a direction it can't go any further in, and it
+// can have come from anywhere it would have had a clear run from.
+func (s *State) ReverseMoves(pos Position) []Move {
+	i, ok := s.at(pos)
+	if !ok {
+		return nil
+	}
+	return s.reverseMoves(s.robots[i], pos, nil)
+}
+
+// reverseMoves appends to `ml` the moves that could have left robot `r` in
+// `pos`. There needn't actually be a robot in `pos`.
+func (s *State) reverseMoves(r Robot, pos Position, ml []Move) []Move {
+	for _, d := range allDirections {
+		if s.CanMove(pos, d) {
+			continue // it wouldn't have stopped here
+		}
+		// It can have come from anywhere it has a clear run back to.
+		back := d.Flip()
+		far := s.Move(pos, back)
+		for from := pos; !from.Equal(far); {
+			from = from.Next(back)
+			ml = append(ml, Move{r, from, d, pos})
+		}
+	}
+	return ml
+}
+
+// without returns a copy of this state without robot `i`.
+func (s *State) without(i int) *State {
+	n := s.Clone()
+	n.robots = append(n.robots[:i], n.robots[i+1:]...)
+	n.pos = append(n.pos[:i], n.pos[i+1:]...)
+	return n
+}
+
+// unreachable marks cells in a solo distance table that can't reach the sink,
+// or not within unreachable-1 moves.
+const unreachable = 255
+
+// maxSoloTables is the most solo distance tables a bidirectional search
+// keeps at once.
+const maxSoloTables = 1 << 14
+
+// soloDistances searches backward from `sink`, returning for every cell the
+// fewest moves a lone robot needs to get from there to `sink` if none of the
+// robots in this state move.
+func (s *State) soloDistances(sink Position) []uint8 {
+	dist := make([]uint8, s.board.size*s.board.size)
+	for i := range dist {
+		dist[i] = unreachable
+	}
+	if _, ok := s.at(sink); ok {
+		return dist
+	}
+
+	// This is `reverseMoves` for every cell, stepping through cell indices.
+	b := s.board
+	steps := [4]int{-b.size, 1, b.size, -1}
+	queue := make([]int, 1, len(dist))
+	queue[0] = b.index(sink)
+	dist[queue[0]] = 0
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		n := dist[c] + 1
+		if n == unreachable {
+			break
+		}
+		p := b.position(c)
+		for _, d := range allDirections {
+			if s.CanMove(p, d) {
+				continue
+			}
+			back := d.Flip()
+			far := b.index(s.Move(p, back))
+			for from := c; from != far; {
+				from += steps[back]
+				if dist[from] == unreachable {
+					dist[from] = n
+					queue = append(queue, from)
+				}
+			}
+		}
+	}
+	return dist
+}
+
+// bidirectional searches forward from the start like `search.bfs`, and for
+// every state it finds, backward from the sink for how many moves the robot
+// that may claim the token would need on its own. The two meet at that
+// robot's position. Every solution ends with one robot moving on its own, so
+// once the forward search is deep enough that it can't find anything shorter,
+// the best solution found is a shortest one. That's usually a whole depth
+// sooner than a breadth-first search, and once a solution is found, states
+// whose lower bound shows they can't beat it are dropped.
+func (sr *search) bidirectional() ([]Move, error) {
+	sink := sr.start.board.sinks[sr.tok]
+	tables := make(map[stateKey][]uint8)
+	size := nodeBytes(len(sr.start.robots))
+	tableSize := sr.start.board.size * sr.start.board.size
+
+	var (
+		best      []Move
+		bestDepth int
+	)
+
+	// meet checks whether any robot in `n`'s state that may claim the token can
+	// get to the sink on its own in fewer moves than the best solution so far.
+	meet := func(n *node) {
+		s := n.state
+		for i, r := range s.robots {
+			if !r.Claims(sr.tok) {
+				continue
+			}
+			others := s.without(i)
+			k := others.key(-1)
+			dist, ok := tables[k]
+			if !ok {
+				if len(tables) == maxSoloTables {
+					sr.memory -= len(tables) * tableSize
+					tables = make(map[stateKey][]uint8)
+				}
+				dist = others.soloDistances(sink)
+				tables[k] = dist
+				sr.memory += tableSize
+			}
+
+			d := dist[s.board.index(s.pos[i])]
+			if d == unreachable || sr.deeper(n.depth+int(d)) {
+				continue
+			}
+			if best == nil || n.depth+int(d) < bestDepth {
+				best = append(n.path(), others.soloPath(r, s.pos[i], dist)...)
+				bestDepth = len(best)
+			}
+		}
+	}
+
+	start := &node{state: sr.start}
+	queue := []*node{start}
+	tried := map[stateKey]bool{sr.start.key(sr.target): true}
+	sr.memory += size
+	meet(start)
+
+	var ml []Move
+	depth := 0
+	for len(queue) > 0 {
+		n := queue[0]
+		queue[0] = nil
+		queue = queue[1:]
+
+		if n.depth >= depth {
+			// Every state up to this depth has been met, and anything found
+			// from here on would be at least a move longer.
+			depth = n.depth + 1
+			if best != nil && bestDepth <= depth {
+				return best, nil
+			}
+			if sr.deeper(depth) {
+				sr.bound = depth
+				return nil, ErrDepthExceeded
+			}
+			sr.deepen(depth)
+		}
+
+		// The best solution may have improved since this state was queued.
+		if best != nil && !sr.improves(n.state, n.depth, bestDepth) {
+			continue
+		}
+		if err := sr.expand(); err != nil {
+			return nil, err
+		}
+
+		ml = n.state.moves(ml[:0])
+		for _, m := range ml {
+			newState := n.state.after(m)
+			hash := newState.key(sr.target)
+			if tried[hash] {
+				sr.stats.StatesDeduplicated++
+				continue
+			}
+			tried[hash] = true
+			if best != nil && !sr.improves(newState, depth, bestDepth) {
+				continue
+			}
+
+			child := &node{newState, n, m, depth}
+			queue = append(queue, child)
+			sr.memory += size
+			meet(child)
+		}
+		sr.frontier(len(queue))
+	}
+
+	return best, nil
+}
+
+// improves returns true if a solution through state `s`, found in `g` moves,
+// could have fewer than `best` moves according to its lower bound. Since the
+// lower bound of a state is never more than one less than its parent's,
+// states that can't improve never lead to any that can.
+func (sr *search) improves(s *State, g, best int) bool {
+	h := s.lowerBound(sr.tok, sr.bounds)
+	return h >= 0 && g+h < best
+}
+
+// soloPath returns the moves robot `r` makes from `pos` to the sink that
+// `dist` was worked out for, with the robots in this state fixed.
+func (s *State) soloPath(r Robot, pos Position, dist []uint8) []Move {
+	var path []Move
+	for d := dist[s.board.index(pos)]; d > 0; d-- {
+		for _, dir := range allDirections {
+			if !s.CanMove(pos, dir) {
+				continue
+			}
+			to := s.Move(pos, dir)
+			if dist[s.board.index(to)] == d-1 {
+				path = append(path, Move{r, pos, dir, to})
+				pos = to
+				break
+			}
+		}
+	}
+	return path
+}
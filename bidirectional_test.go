@@ -0,0 +1,90 @@
+package ricochet
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStateReverseMoves(t *testing.T) {
+	_, s := readTestBoard(t)
+
+	for i := range s.robots {
+		r, end := s.robots[i], s.pos[i]
+
+		// Try moving the robot from every other cell to see which end up here.
+		var exp []Move
+		others := s.without(i)
+		for x := 0; x < s.board.size; x++ {
+			for y := 0; y < s.board.size; y++ {
+				from := Position{x, y}
+				if from.Equal(end) || !s.board.InBounds(from) {
+					continue
+				}
+				if _, ok := others.at(from); ok {
+					continue
+				}
+				for _, d := range allDirections {
+					if others.CanMove(from, d) && others.Move(from, d).Equal(end) {
+						exp = append(exp, Move{r, from, d, end})
+					}
+				}
+			}
+		}
+
+		act := s.ReverseMoves(end)
+		if len(act) != len(exp) {
+			t.Errorf("expected %d reverse moves to %v, got %d: %+v",
+				len(exp), end, len(act), act)
+			continue
+		}
+		found := make(map[Move]bool)
+		for _, m := range act {
+			found[m] = true
+		}
+		for _, m := range exp {
+			if !found[m] {
+				t.Errorf("expected reverse move %+v", m)
+			}
+		}
+	}
+
+	if ml := s.ReverseMoves(Position{0, 0}); ml != nil {
+		t.Errorf("expected no reverse moves without a robot, got %v", ml)
+	}
+}
+
+func TestSolverBidirectional(t *testing.T) {
+	_, s := readTestBoard(t)
+	bfs := NewSolver(SolveOptions{})
+	bi := NewSolver(SolveOptions{Algorithm: AlgorithmBidirectional})
+
+	for _, test := range solveTests {
+		exp, err := bfs.Solve(context.Background(), s, test.Token)
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		act, err := bi.Solve(context.Background(), s, test.Token)
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		if len(act.Moves) != len(exp.Moves) {
+			t.Errorf("expected %v in %d moves, got %d",
+				test.Token, len(exp.Moves), len(act.Moves))
+		}
+		if err := s.board.Verify(s, test.Token, act.Moves); err != nil {
+			t.Errorf("expected %v to verify, got %v", test.Token, err)
+		}
+		if act.Stats.NodesExpanded > exp.Stats.NodesExpanded {
+			t.Errorf("expected %v to expand at most %d states, got %d",
+				test.Token, exp.Stats.NodesExpanded, act.Stats.NodesExpanded)
+		}
+	}
+}
+
+func BenchmarkSolveBidirectional(b *testing.B) {
+	sv := NewSolver(SolveOptions{Algorithm: AlgorithmBidirectional})
+	benchmarkSolve(b, func(s *State, tok Token) []Move {
+		sol, _ := sv.Solve(context.Background(), s, tok)
+		return sol.Moves
+	})
+}
@@ -0,0 +1,256 @@
+package ricochet
+
+import (
+	"bufio"
+	"container/list"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// A SolutionCache holds on to solutions so that `Solver.Solve` doesn't have
+// to search for them again. Moves are nil if there's no solution. It must be
+// safe to use from more than one goroutine.
+type SolutionCache interface {
+	Get(key CacheKey) ([]Move, bool)
+	Put(key CacheKey, moves []Move)
+}
+
+// CacheKey identifies a puzzle: a hash of the walls, oob cells and sinks on
+// the board, where the robots are, and the token. Boards that are built
+// differently but that robots move around in the same way have the same key.
+type CacheKey [sha256.Size]byte
+
+func (k CacheKey) String() string {
+	return hex.EncodeToString(k[:])
+}
+
+// NewCacheKey returns the key for solving for `tok` from `s`.
+func NewCacheKey(s *State, tok Token) CacheKey {
+	h := sha256.New()
+	s.board.writeCanonical(h)
+	binary.Write(h, binary.BigEndian, int32(len(s.robots)))
+	for i, r := range s.robots {
+		binary.Write(h, binary.BigEndian, []int32{int32(r.Colour),
+			int32(s.pos[i].X), int32(s.pos[i].Y)})
+	}
+	binary.Write(h, binary.BigEndian, []int32{int32(tok.Shape), int32(tok.Colour)})
+
+	var k CacheKey
+	h.Sum(k[:0])
+	return k
+}
+
+// writeCanonical writes everything about this board that affects solving: for
+// each cell, whether it's oob and which ways a robot can slide out of it, and
+// then the sinks by token.
+func (b *Board) writeCanonical(w io.Writer) {
+	binary.Write(w, binary.BigEndian, int32(b.size))
+	cells := make([]uint8, len(b.cells))
+	for i := range cells {
+		p := b.position(i)
+		cells[i] = b.cells[i] & cellOOB
+		for _, d := range allDirections {
+			if b.canSlide(p, d) {
+				cells[i] |= 1 << uint(d)
+			}
+		}
+	}
+	w.Write(cells)
+	for _, tok := range b.tokens() {
+		p := b.sinks[tok]
+		binary.Write(w, binary.BigEndian, []int32{int32(tok.Shape),
+			int32(tok.Colour), int32(p.X), int32(p.Y)})
+	}
+}
+
+// copyMoves returns a copy of `moves`, keeping nil and empty apart.
+func copyMoves(moves []Move) []Move {
+	if moves == nil {
+		return nil
+	}
+	return append([]Move{}, moves...)
+}
+
+// LRUCache is a SolutionCache in memory that holds a limited number of
+// solutions, dropping the least recently used.
+type LRUCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *lruEntry, most recently used first
+	entries map[CacheKey]*list.Element
+}
+
+type lruEntry struct {
+	key   CacheKey
+	moves []Move
+}
+
+// NewLRUCache returns a cache that holds up to `size` solutions.
+func NewLRUCache(size int) *LRUCache {
+	return &LRUCache{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[CacheKey]*list.Element),
+	}
+}
+
+func (c *LRUCache) Get(key CacheKey) ([]Move, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return copyMoves(e.Value.(*lruEntry).moves), true
+}
+
+func (c *LRUCache) Put(key CacheKey, moves []Move) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*lruEntry).moves = copyMoves(moves)
+		c.order.MoveToFront(e)
+		return
+	}
+	if c.size <= 0 {
+		return
+	}
+	if c.order.Len() >= c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*lruEntry).key)
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key, copyMoves(moves)})
+}
+
+// Len returns the number of solutions in the cache.
+func (c *LRUCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// FileCache is a SolutionCache that keeps every solution in memory and
+// appends each new one to a file, one JSON object per line, so that it
+// persists between runs.
+type FileCache struct {
+	mu      sync.Mutex
+	f       *os.File
+	entries map[CacheKey][]Move
+	err     error // the first error writing to the file
+}
+
+type fileCacheEntry struct {
+	Key   string
+	Moves []Move
+}
+
+// OpenFileCache reads the solutions in the file at `path`, creating it if it
+// doesn't exist, and returns a cache that adds to it. A partly written last
+// line, as left by a crash, is cut off so that the next solution starts a new
+// line.
+func OpenFileCache(path string) (*FileCache, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return nil, err
+	}
+	c := &FileCache{f: f, entries: make(map[CacheKey][]Move)}
+
+	r := bufio.NewReader(f)
+	var size int64 // the length of the lines that were finished
+	for line := 1; ; line++ {
+		b, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// Without a newline, the line wasn't finished.
+			if len(b) > 0 {
+				if err := f.Truncate(size); err != nil {
+					f.Close()
+					return nil, err
+				}
+			}
+			break
+		} else if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		key, moves, err := parseFileCacheEntry(b)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("error line %d: %v", line, err)
+		}
+		c.entries[key] = moves
+		size += int64(len(b))
+	}
+	return c, nil
+}
+
+func parseFileCacheEntry(b []byte) (CacheKey, []Move, error) {
+	var (
+		e   fileCacheEntry
+		key CacheKey
+	)
+	if err := json.Unmarshal(b, &e); err != nil {
+		return key, nil, err
+	}
+	k, err := hex.DecodeString(e.Key)
+	if err != nil || len(k) != len(key) {
+		return key, nil, errors.New("bad key")
+	}
+	copy(key[:], k)
+	return key, e.Moves, nil
+}
+
+func (c *FileCache) Get(key CacheKey) ([]Move, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	moves, ok := c.entries[key]
+	return copyMoves(moves), ok
+}
+
+// Put adds a solution to the cache and the file. Errors writing to the file
+// are returned by `Close`.
+func (c *FileCache) Put(key CacheKey, moves []Move) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = copyMoves(moves)
+
+	if c.err != nil {
+		return
+	}
+	b, err := json.Marshal(fileCacheEntry{key.String(), moves})
+	if err == nil {
+		_, err = c.f.Write(append(b, '\n'))
+	}
+	c.err = err
+}
+
+// Len returns the number of solutions in the cache.
+func (c *FileCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Close closes the file, returning the first error writing to it if there was
+// one.
+func (c *FileCache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	err := c.f.Close()
+	if c.err != nil {
+		return c.err
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+package ricochet
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewCacheKey(t *testing.T) {
+	red := Token{ShapeCircle, ColourRed}
+	newState := func(wall Position, dir Direction, robot Position) *State {
+		b, _ := NewBoard(5)
+		b.AddWall(wall, dir)
+		b.AddSink(red, Position{4, 4})
+		s := b.NewState()
+		s.AddRobot(robot, Robot{ColourRed})
+		return s
+	}
+
+	// The same wall, added from either side.
+	k := NewCacheKey(newState(Position{1, 1}, DirectionEast, Position{0, 0}), red)
+	if k2 := NewCacheKey(newState(Position{2, 1}, DirectionWest, Position{0, 0}), red); k2 != k {
+		t.Errorf("expected the same key for the same wall, got %v and %v", k, k2)
+	}
+	if len(k.String()) != 64 {
+		t.Errorf("expected 64 hex digits, got %q", k.String())
+	}
+
+	for _, k2 := range []CacheKey{
+		NewCacheKey(newState(Position{1, 1}, DirectionSouth, Position{0, 0}), red),
+		NewCacheKey(newState(Position{1, 1}, DirectionEast, Position{0, 1}), red),
+		NewCacheKey(newState(Position{1, 1}, DirectionEast, Position{0, 0}),
+			Token{ShapeHexagon, ColourRed}),
+	} {
+		if k2 == k {
+			t.Errorf("expected different keys, got %v", k)
+		}
+	}
+}
+
+func TestLRUCache(t *testing.T) {
+	var keys [4]CacheKey
+	for i := range keys {
+		keys[i][0] = byte(i)
+	}
+	moves := []Move{{Robot{ColourRed}, Position{0, 0}, DirectionEast, Position{4, 0}}}
+
+	c := NewLRUCache(2)
+	c.Put(keys[0], moves)
+	c.Put(keys[1], nil)
+	if got, ok := c.Get(keys[0]); !ok || !reflect.DeepEqual(got, moves) {
+		t.Errorf("expected %v, got %v, %v", moves, got, ok)
+	}
+
+	// The least recently used solution is dropped.
+	c.Put(keys[2], []Move{})
+	if _, ok := c.Get(keys[1]); ok {
+		t.Errorf("expected key 1 to be dropped")
+	}
+	if got, ok := c.Get(keys[2]); !ok || got == nil || len(got) != 0 {
+		t.Errorf("expected an empty solution, got %v, %v", got, ok)
+	}
+	if c.Len() != 2 {
+		t.Errorf("expected 2 solutions, got %d", c.Len())
+	}
+
+	// What's returned is a copy.
+	got, _ := c.Get(keys[0])
+	got[0].Direction = DirectionWest
+	if got, _ := c.Get(keys[0]); got[0].Direction != DirectionEast {
+		t.Errorf("expected the cached solution not to change")
+	}
+}
+
+func TestFileCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ricochet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cache")
+
+	var keys [3]CacheKey
+	for i := range keys {
+		keys[i][31] = byte(i)
+	}
+	moves := []Move{{Robot{ColourRed}, Position{0, 0}, DirectionEast, Position{4, 0}}}
+
+	c, err := OpenFileCache(path)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	c.Put(keys[0], moves)
+	c.Put(keys[1], nil)
+	c.Put(keys[2], []Move{})
+	if err := c.Close(); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	// A crash can leave part of a line at the end.
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0666)
+	f.WriteString(`{"Key":"00`)
+	f.Close()
+
+	c, err = OpenFileCache(path)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if c.Len() != 3 {
+		t.Errorf("expected 3 solutions, got %d", c.Len())
+	}
+	if got, ok := c.Get(keys[0]); !ok || !reflect.DeepEqual(got, moves) {
+		t.Errorf("expected %v, got %v, %v", moves, got, ok)
+	}
+	if got, ok := c.Get(keys[1]); !ok || got != nil {
+		t.Errorf("expected no solution, got %v, %v", got, ok)
+	}
+	if got, ok := c.Get(keys[2]); !ok || got == nil || len(got) != 0 {
+		t.Errorf("expected an empty solution, got %v, %v", got, ok)
+	}
+
+	// A solution added after that starts a new line.
+	var key CacheKey
+	key[0] = 1
+	c.Put(key, moves)
+	if err := c.Close(); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	c, err = OpenFileCache(path)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	defer c.Close()
+	if c.Len() != 4 {
+		t.Errorf("expected 4 solutions, got %d", c.Len())
+	}
+	for _, k := range []CacheKey{keys[0], key} {
+		if got, ok := c.Get(k); !ok || !reflect.DeepEqual(got, moves) {
+			t.Errorf("expected %v, got %v, %v", moves, got, ok)
+		}
+	}
+
+	bad := filepath.Join(dir, "bad")
+	ioutil.WriteFile(bad, []byte("not json\n"), 0666)
+	if _, err := OpenFileCache(bad); err == nil {
+		t.Errorf("expected error reading a bad cache")
+	}
+}
+
+func TestSolverCache(t *testing.T) {
+	_, s := readTestBoard(t)
+	tok := Token{ShapeHexagon, ColourBlue} // 7 moves
+	cache := NewLRUCache(16)
+	sv := NewSolver(SolveOptions{Cache: cache})
+
+	sol, err := sv.Solve(context.Background(), s, tok)
+	if err != nil || sol.Cached || len(sol.Moves) != 7 {
+		t.Fatalf("expected 7 moves searching, got %v, %v, %v", sol.Moves,
+			sol.Cached, err)
+	}
+	again, err := sv.Solve(context.Background(), s, tok)
+	if err != nil || !again.Cached || !reflect.DeepEqual(again.Moves, sol.Moves) {
+		t.Errorf("expected %v from the cache, got %v, %v, %v", sol.Moves,
+			again.Moves, again.Cached, err)
+	}
+	if again.LowerBound != 7 || again.Stats.NodesExpanded != 0 {
+		t.Errorf("expected lower bound 7 and no search, got %d and %d",
+			again.LowerBound, again.Stats.NodesExpanded)
+	}
+
+	// A cached solution is still too deep.
+	sv = NewSolver(SolveOptions{Cache: cache, MaxDepth: 5})
+	sol, err = sv.Solve(context.Background(), s, tok)
+	if err != ErrDepthExceeded || sol.Moves != nil || sol.LowerBound != 7 {
+		t.Errorf("expected %v with lower bound 7, got %v, %d", ErrDepthExceeded,
+			err, sol.LowerBound)
+	}
+
+	// Searches that stop early aren't cached.
+	tok = Token{ShapeHexagon, ColourRed}
+	if _, err := sv.Solve(context.Background(), s, tok); err != ErrDepthExceeded {
+		t.Errorf("expected %v, got %v", ErrDepthExceeded, err)
+	}
+	if _, ok := cache.Get(NewCacheKey(s, tok)); ok {
+		t.Errorf("expected no solution in the cache")
+	}
+}
@@ -0,0 +1,197 @@
+// Command ricochet-dataset generates random puzzles on the boards in the files
+// given, which are in the format `ricochet.ReadBoard` reads, solves each of
+// them, and writes them to stdout as JSON Lines. The robots in the files are
+// ignored. The same seed always gives the same puzzles, in the same order.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/neilgarb/ricochet"
+)
+
+var (
+	count   = flag.Int("n", 100, "the number of puzzles")
+	seed    = flag.Int64("seed", 1, "the seed for generating puzzles")
+	workers = flag.Int("workers", 0, "the number of puzzles to solve at "+
+		"once, or 0 for one for each CPU")
+	maxNodes = flag.Int("max-nodes", 0, "the most states to expand solving "+
+		"each puzzle, or 0 for no limit")
+)
+
+// record is a line of output.
+type record struct {
+	Board    string  `json:"board"` // the file the board was read from
+	Robots   []robot `json:"robots"`
+	Token    token   `json:"token"`
+	Moves    int     `json:"moves"` // -1 if there's no solution
+	Solution string  `json:"solution"`
+	Stats    stats   `json:"stats"`
+	Error    string  `json:"error,omitempty"` // if solving stopped early
+}
+
+type robot struct {
+	Colour ricochet.Colour `json:"colour"`
+	X      int             `json:"x"`
+	Y      int             `json:"y"`
+}
+
+type token struct {
+	Shape  ricochet.Shape  `json:"shape"`
+	Colour ricochet.Colour `json:"colour"`
+}
+
+type stats struct {
+	NodesExpanded      int   `json:"nodes_expanded"`
+	StatesDeduplicated int   `json:"states_deduplicated"`
+	MaxFrontier        int   `json:"max_frontier"`
+	ElapsedNanos       int64 `json:"elapsed_ns"`
+}
+
+// job is a puzzle to solve, and where it goes in the output.
+type job struct {
+	i      int
+	board  string
+	puzzle *ricochet.Puzzle
+}
+
+// result is a solved puzzle, and where it goes in the output.
+type result struct {
+	i   int
+	rec *record
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s [flags] board...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var boards []*ricochet.Board
+	for _, path := range flag.Args() {
+		b, err := readBoard(path)
+		if err != nil {
+			panic(fmt.Errorf("%s: %v", path, err))
+		}
+		boards = append(boards, b)
+	}
+
+	n := *workers
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	sv := ricochet.NewSolver(ricochet.SolveOptions{
+		Algorithm: ricochet.AlgorithmAStar,
+		MaxNodes:  *maxNodes,
+	})
+
+	// Puzzles are generated in order, so that they only depend on the seed.
+	jobs := make(chan job)
+	go func() {
+		r := rand.New(rand.NewSource(*seed))
+		for i := 0; i < *count; i++ {
+			j := r.Intn(len(boards))
+			p, err := ricochet.RandomPuzzle(r, boards[j])
+			if err != nil {
+				panic(err)
+			}
+			jobs <- job{i, flag.Arg(j), p}
+		}
+		close(jobs)
+	}()
+
+	results := make(chan result)
+	var wg sync.WaitGroup
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results <- result{j.i, solve(sv, j)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Puzzles are written in order as they're solved.
+	w := bufio.NewWriter(os.Stdout)
+	enc := json.NewEncoder(w)
+	pending := make(map[int]*record)
+	next := 0
+	for res := range results {
+		pending[res.i] = res.rec
+		for rec, ok := pending[next]; ok; rec, ok = pending[next] {
+			if err := enc.Encode(rec); err != nil {
+				panic(err)
+			}
+			delete(pending, next)
+			next++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		panic(err)
+	}
+}
+
+// solve solves the puzzle in `j`.
+func solve(sv *ricochet.Solver, j job) *record {
+	p := j.puzzle
+	rec := &record{
+		Board: j.board,
+		Token: token{p.Token.Shape, p.Token.Colour},
+		Moves: -1,
+	}
+	for _, r := range p.Start.Robots() {
+		pos, _ := p.Start.Find(r.Colour)
+		rec.Robots = append(rec.Robots, robot{r.Colour, pos.X, pos.Y})
+	}
+
+	sol, err := sv.Solve(context.Background(), p.Start, p.Token)
+	if err != nil {
+		rec.Error = err.Error()
+	} else if sol.Moves != nil {
+		rec.Moves = len(sol.Moves)
+		rec.Solution = ricochet.FormatMoves(sol.Moves)
+	}
+	rec.Stats = stats{
+		NodesExpanded:      sol.Stats.NodesExpanded,
+		StatesDeduplicated: sol.Stats.StatesDeduplicated,
+		MaxFrontier:        sol.Stats.MaxFrontier,
+		ElapsedNanos:       int64(sol.Stats.Elapsed / time.Nanosecond),
+	}
+	return rec
+}
+
+func readBoard(path string) (*ricochet.Board, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	b, _, err := ricochet.ReadBoard(bufio.NewReader(f))
+	if err != nil {
+		return nil, err
+	}
+	if !b.Valid() {
+		return nil, errors.New("invalid board")
+	}
+	return b, nil
+}
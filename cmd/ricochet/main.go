@@ -2,14 +2,26 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"os"
 
 	"github.com/neilgarb/ricochet"
 )
 
+var (
+	verbose  = flag.Bool("v", false, "report progress on stderr")
+	patterns = flag.String("patterns", "", "solve with A* using the pattern "+
+		"database in `file`, creating or adding to it")
+	prove = flag.Bool("prove", false, "if there's no solution, make sure of it "+
+		"by trying every reachable state")
+)
+
 func main() {
+	flag.Parse()
+
 	b, s, err := ricochet.ReadBoard(bufio.NewReader(os.Stdin))
 	if err != nil {
 		panic(err)
@@ -17,6 +29,74 @@ func main() {
 	if !b.Valid() {
 		panic(errors.New("invalid board"))
 	}
-	ml := s.Solve(ricochet.Token{ricochet.ShapeCircle, ricochet.ColourBlue})
-	fmt.Printf("%+v\n", ml)
+
+	var opts ricochet.SolveOptions
+	if *verbose {
+		opts.Progress = func(stats ricochet.SolveStats) {
+			d := stats.Depths[len(stats.Depths)-1]
+			fmt.Fprintf(os.Stderr, "Trying depth %d (%d states expanded)\n",
+				d.Depth, stats.NodesExpanded)
+		}
+	}
+
+	if *patterns != "" {
+		db, err := readPatterns(*patterns, b)
+		if err != nil {
+			panic(err)
+		}
+		opts.Algorithm = ricochet.AlgorithmAStar
+		opts.Patterns = db
+		defer func() {
+			if err := writePatterns(*patterns, db); err != nil {
+				panic(err)
+			}
+		}()
+	}
+
+	tok := ricochet.Token{Shape: ricochet.ShapeCircle, Colour: ricochet.ColourBlue}
+	if *prove {
+		p, err := ricochet.NewSolver(opts).Prove(context.Background(), s, tok)
+		if err != nil {
+			panic(err)
+		}
+		if p.Unsolvable {
+			fmt.Printf("no solution (%d reachable states)\n", p.States)
+			return
+		}
+		fmt.Println(ricochet.FormatMoves(p.Moves))
+		return
+	}
+
+	sol, err := ricochet.NewSolver(opts).Solve(context.Background(), s, tok)
+	if err != nil {
+		panic(err)
+	}
+	if sol.Moves == nil {
+		fmt.Println("no solution")
+		return
+	}
+	fmt.Println(ricochet.FormatMoves(sol.Moves))
+}
+
+func readPatterns(path string, b *ricochet.Board) (*ricochet.PatternDB, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return ricochet.NewPatternDB(b), nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ricochet.ReadPatternDB(f, b)
+}
+
+func writePatterns(path string, db *ricochet.PatternDB) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := db.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
 }
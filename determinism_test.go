@@ -0,0 +1,102 @@
+package ricochet
+
+import (
+	"context"
+	"testing"
+)
+
+// goldenSolutions are the solutions each algorithm finds on the test board.
+// Breadth-first search, IDA* and parallel breadth-first search find the same
+// ones; A* and bidirectional search only differ where they're set.
+var goldenSolutions = []struct {
+	tok               Token
+	bfs, astar, bidir string
+}{
+	{Token{ShapeCircle, ColourBlue}, "BE BN GN BW BS", "", "GN BE BN BW BS"},
+	{Token{ShapeCircle, ColourYellow}, "YS YW YS YW", "", ""},
+	{Token{ShapeCircle, ColourGreen}, "GW GS GE GS", "", ""},
+	{Token{ShapeCircle, ColourRed}, "BE BN RW RN RE RN RE", "RW RN RE GN GE RN RE", ""},
+	{Token{ShapeTriangle, ColourBlue}, "BS", "", ""},
+	{Token{ShapeTriangle, ColourGreen}, "GN GE GS", "", ""},
+	{Token{ShapeTriangle, ColourRed}, "RE RS RW RN", "", ""},
+	{Token{ShapeDiamond, ColourBlue}, "BW BS BE BN BW BN", "", ""},
+	{Token{ShapeDiamond, ColourYellow}, "YS", "", ""},
+	{Token{ShapeDiamond, ColourGreen}, "YW GS GE GS GW", "GS GE YW GS GW", ""},
+	{Token{ShapeDiamond, ColourRed}, "RS RW RN", "", ""},
+	{Token{ShapeHexagon, ColourBlue}, "BW BS BE BN BE BS BW", "", ""},
+	{Token{ShapeHexagon, ColourYellow}, "YN YE RN RW YW YS", "", "RN RW YE YN YW YS"},
+	{Token{ShapeHexagon, ColourGreen}, "YS YW YS GN GW GS GE", "GN GW YS YW YS GS GE", ""},
+}
+
+func TestSolverDeterministic(t *testing.T) {
+	solvers := []struct {
+		alg  Algorithm
+		opts SolveOptions
+	}{
+		{AlgorithmBFS, SolveOptions{}},
+		{AlgorithmAStar, SolveOptions{Algorithm: AlgorithmAStar}},
+		{AlgorithmIDA, SolveOptions{Algorithm: AlgorithmIDA}},
+		{AlgorithmIDA, SolveOptions{Algorithm: AlgorithmIDA, TableSize: 1 << 16}},
+		{AlgorithmParallelBFS, SolveOptions{Algorithm: AlgorithmParallelBFS, Workers: 1}},
+		{AlgorithmParallelBFS, SolveOptions{Algorithm: AlgorithmParallelBFS, Workers: 3}},
+		{AlgorithmBidirectional, SolveOptions{Algorithm: AlgorithmBidirectional}},
+	}
+
+	// Each run reads the board again, so nothing carries over between them.
+	for run := 0; run < 2; run++ {
+		_, s := readTestBoard(t)
+		for _, sv := range solvers {
+			solver := NewSolver(sv.opts)
+			for _, test := range goldenSolutions {
+				want := test.bfs
+				switch {
+				case sv.alg == AlgorithmAStar && test.astar != "":
+					want = test.astar
+				case sv.alg == AlgorithmBidirectional && test.bidir != "":
+					want = test.bidir
+				}
+
+				sol, err := solver.Solve(context.Background(), s, test.tok)
+				if err != nil {
+					t.Fatalf("expected success, got %v", err)
+				}
+				if got := FormatMovesASCII(sol.Moves); got != want {
+					t.Errorf("run %d: %+v: %v: expected %q, got %q", run,
+						sv.opts, test.tok, want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestSolverDeterministicRankingAndAll(t *testing.T) {
+	tok := Token{ShapeHexagon, ColourYellow}
+	rk := &Ranking{Weights: map[Colour]int{ColourRed: 1}}
+	var ranked, all []string
+	for run := 0; run < 3; run++ {
+		_, s := readTestBoard(t)
+		sol, err := NewSolver(SolveOptions{Ranking: rk}).Solve(
+			context.Background(), s, tok)
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+		ranked = append(ranked, FormatMovesASCII(sol.Moves))
+
+		text := ""
+		for _, moves := range s.SolveAll(tok, 0) {
+			text += FormatMovesASCII(moves) + "\n"
+		}
+		all = append(all, text)
+	}
+
+	for run := 1; run < 3; run++ {
+		if ranked[run] != ranked[0] {
+			t.Errorf("run %d: expected ranked solution %q, got %q", run,
+				ranked[0], ranked[run])
+		}
+		if all[run] != all[0] {
+			t.Errorf("run %d: expected solutions %q, got %q", run, all[0],
+				all[run])
+		}
+	}
+}
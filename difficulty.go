@@ -0,0 +1,123 @@
+package ricochet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// ErrNoPuzzle means no puzzle met the difficulty in the attempts allowed.
+var ErrNoPuzzle = errors.New("no puzzle found")
+
+// Difficulty describes the puzzles `Generate` looks for.
+type Difficulty struct {
+	// Moves is the number of moves a shortest solution must have.
+	Moves int
+
+	// MinRobots is the fewest robots every shortest solution must move. 0
+	// means any number.
+	MinRobots int
+
+	// MaxNodes is the most states that may be expanded solving each puzzle.
+	// 0 means no limit.
+	MaxNodes int
+
+	// MaxAttempts is the most puzzles to try. 0 means no limit.
+	MaxAttempts int
+}
+
+// Candidate is a puzzle tried by `Generate`.
+type Candidate struct {
+	*Puzzle
+
+	// Solution is what solving the puzzle found, which may be incomplete if
+	// the search stopped early.
+	Solution *Solution
+
+	// Reason says why the puzzle was rejected, or is empty if it wasn't.
+	Reason string
+}
+
+// Generate tries random puzzles on board `b`, or on a random 16x16 board from
+// `RandomBoard` for each if it's nil, until one meets difficulty `d`. It
+// returns that puzzle, and every puzzle rejected before it along with why. If
+// none is found in the attempts allowed the error is `ErrNoPuzzle`. The same
+// source of random numbers, in the same state, always gives the same results.
+func Generate(ctx context.Context, r *rand.Rand, b *Board, d Difficulty) (*Candidate, []Candidate, error) {
+	if d.Moves < 1 {
+		return nil, nil, errors.New("invalid number of moves")
+	}
+
+	var rejected []Candidate
+	for i := 0; d.MaxAttempts == 0 || i < d.MaxAttempts; i++ {
+		pb := b
+		if pb == nil {
+			var err error
+			if pb, err = RandomBoard(r, 16); err != nil {
+				return nil, rejected, err
+			}
+		}
+		p, err := RandomPuzzle(r, pb)
+		if err != nil {
+			return nil, rejected, err
+		}
+
+		c, err := d.try(ctx, p)
+		if err != nil {
+			return nil, rejected, err
+		}
+		if c.Reason == "" {
+			return c, rejected, nil
+		}
+		rejected = append(rejected, *c)
+	}
+	return nil, rejected, ErrNoPuzzle
+}
+
+// try solves puzzle `p` and checks it against the difficulty. The error is
+// only set if the context is done.
+func (d Difficulty) try(ctx context.Context, p *Puzzle) (*Candidate, error) {
+	opts := SolveOptions{
+		Algorithm: AlgorithmAStar,
+		MaxDepth:  d.Moves,
+		MaxNodes:  d.MaxNodes,
+	}
+	c := &Candidate{Puzzle: p}
+	sol, err := NewSolver(opts).Solve(ctx, p.Start, p.Token)
+	c.Solution = sol
+	switch {
+	case err == ErrDepthExceeded:
+		c.Reason = fmt.Sprintf("needs more than %d moves", d.Moves)
+		return c, nil
+	case err == ErrBudgetExceeded:
+		c.Reason = fmt.Sprintf("ran out of budget, needs at least %d moves",
+			sol.LowerBound)
+		return c, nil
+	case err != nil:
+		return nil, err
+	case sol.Moves == nil:
+		c.Reason = "unsolvable"
+		return c, nil
+	case len(sol.Moves) < d.Moves:
+		c.Reason = fmt.Sprintf("needs only %d moves", len(sol.Moves))
+		return c, nil
+	}
+
+	if d.MinRobots > 1 {
+		// The best solution under an empty ranking moves the fewest robots.
+		opts.Ranking = &Ranking{}
+		sol, err = NewSolver(opts).Solve(ctx, p.Start, p.Token)
+		if err == ErrBudgetExceeded {
+			c.Reason = "ran out of budget counting robots"
+			return c, nil
+		} else if err != nil {
+			return nil, err
+		}
+		c.Solution = sol
+		if n := opts.Ranking.Cost(sol.Moves).Robots; n < d.MinRobots {
+			c.Reason = fmt.Sprintf("needs only %d robots", n)
+		}
+	}
+	return c, nil
+}
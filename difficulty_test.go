@@ -0,0 +1,85 @@
+package ricochet
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	b, _ := readTestBoard(t)
+	tests := []Difficulty{
+		{Moves: 3},
+		{Moves: 5, MaxNodes: 10000},
+		{Moves: 4, MinRobots: 2},
+	}
+	for _, d := range tests {
+		c, rejected, err := Generate(context.Background(),
+			rand.New(rand.NewSource(1)), b, d)
+		if err != nil {
+			t.Fatalf("%+v: expected success, got %v", d, err)
+		}
+		if c.Reason != "" {
+			t.Errorf("%+v: expected no reason, got %q", d, c.Reason)
+		}
+		if n := len(c.Start.Solve(c.Token)); n != d.Moves {
+			t.Errorf("%+v: expected %d moves, got %d", d, d.Moves, n)
+		}
+		if err := b.Verify(c.Start, c.Token, c.Solution.Moves); err != nil {
+			t.Errorf("%+v: %v", d, err)
+		}
+		if n := (&Ranking{}).Cost(c.Solution.Moves).Robots; n < d.MinRobots {
+			t.Errorf("%+v: expected at least %d robots, got %d", d, d.MinRobots, n)
+		}
+		for _, r := range rejected {
+			if r.Reason == "" {
+				t.Errorf("%+v: expected a reason for %v", d, r.Start)
+			}
+		}
+
+		// The same seed gives the same puzzles.
+		c2, rejected2, _ := Generate(context.Background(),
+			rand.New(rand.NewSource(1)), b, d)
+		if c2.Start.String() != c.Start.String() || c2.Token != c.Token ||
+			len(rejected2) != len(rejected) {
+			t.Errorf("%+v: expected the same puzzle", d)
+		}
+	}
+}
+
+func TestGenerateRejections(t *testing.T) {
+	b, _ := readTestBoard(t)
+	d := Difficulty{Moves: 1, MinRobots: 2, MaxAttempts: 20}
+	_, rejected, err := Generate(context.Background(), rand.New(rand.NewSource(1)), b, d)
+	if err != ErrNoPuzzle {
+		t.Errorf("expected %v, got %v", ErrNoPuzzle, err)
+	}
+	if len(rejected) != 20 {
+		t.Fatalf("expected 20 rejections, got %d", len(rejected))
+	}
+	reasons := make(map[string]bool)
+	for _, r := range rejected {
+		reasons[r.Reason] = true
+	}
+	for _, reason := range []string{"needs more than 1 moves", "needs only 1 robots"} {
+		if !reasons[reason] {
+			t.Errorf("expected a rejection because it %s, got %v", reason, reasons)
+		}
+	}
+
+	if _, _, err := Generate(context.Background(), rand.New(rand.NewSource(1)), b,
+		Difficulty{}); err == nil {
+		t.Errorf("expected an error for no moves")
+	}
+}
+
+func TestGenerateRandomBoard(t *testing.T) {
+	d := Difficulty{Moves: 3, MaxAttempts: 100}
+	c, _, err := Generate(context.Background(), rand.New(rand.NewSource(2)), nil, d)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if n := len(c.Start.Solve(c.Token)); n != 3 {
+		t.Errorf("expected 3 moves, got %d", n)
+	}
+}
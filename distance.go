@@ -0,0 +1,52 @@
+package ricochet
+
+import "errors"
+
+// Distances returns, for every cell on the board, the fewest moves robot `r`
+// needs to get there on its own, with the other robots staying where they
+// are. The grid is indexed by row and then column, i.e. `[pos.Y][pos.X]`, and
+// cells the robot can't get to hold -1.
+func (s *State) Distances(r Robot) ([][]int, error) {
+	i := -1
+	for j, r2 := range s.robots {
+		if r2 == r {
+			i = j
+		}
+	}
+	if i < 0 {
+		return nil, errors.New("robot isn't on the board")
+	}
+
+	b := s.board
+	dist := make([]int, b.size*b.size)
+	for j := range dist {
+		dist[j] = -1
+	}
+	dist[b.index(s.pos[i])] = 0
+
+	// The robot isn't in its own way.
+	others := s.without(i)
+	queue := []Position{s.pos[i]}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+
+		n := dist[b.index(p)] + 1
+		for _, d := range allDirections {
+			if !others.CanMove(p, d) {
+				continue
+			}
+			to := others.Move(p, d)
+			if j := b.index(to); dist[j] < 0 {
+				dist[j] = n
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	grid := make([][]int, b.size)
+	for y := range grid {
+		grid[y] = dist[y*b.size : (y+1)*b.size]
+	}
+	return grid, nil
+}
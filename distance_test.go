@@ -0,0 +1,63 @@
+package ricochet
+
+import "testing"
+
+func TestStateDistances(t *testing.T) {
+	b, _ := NewBoard(5)
+	s := b.NewState()
+	s.AddRobot(Position{0, 0}, Robot{ColourRed})
+	s.AddRobot(Position{2, 4}, Robot{ColourBlue})
+
+	grid, err := s.Distances(Robot{ColourRed})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	exp := [][]int{
+		{0, 3, -1, 4, 1},
+		{-1, -1, -1, -1, -1},
+		{-1, -1, -1, -1, -1},
+		{-1, -1, -1, -1, -1},
+		{1, 2, -1, 3, 2}, // the blue robot stops the red one short
+	}
+	for y := range exp {
+		for x := range exp[y] {
+			if grid[y][x] != exp[y][x] {
+				t.Errorf("expected %d at %v, got %d", exp[y][x], Position{x, y},
+					grid[y][x])
+			}
+		}
+	}
+
+	if _, err := s.Distances(Robot{ColourGreen}); err == nil {
+		t.Errorf("expected error for a robot that isn't on the board")
+	}
+}
+
+func TestStateDistancesMatchSolo(t *testing.T) {
+	_, s := readTestBoard(t)
+	for i, r := range s.robots {
+		grid, err := s.Distances(r)
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+
+		// Searching backward from each cell gets the same distance.
+		others := s.without(i)
+		for y := range grid {
+			for x := range grid[y] {
+				if !s.board.InBounds(Position{x, y}) {
+					continue
+				}
+				solo := others.soloDistances(Position{x, y})[s.board.index(s.pos[i])]
+				exp := int(solo)
+				if solo == unreachable {
+					exp = -1
+				}
+				if grid[y][x] != exp {
+					t.Errorf("robot %d: expected %d at %v, got %d", r.Colour, exp,
+						Position{x, y}, grid[y][x])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package ricochet
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// Puzzle is a board, the robots on it and the token to claim.
+type Puzzle struct {
+	Board *Board
+	Start *State
+	Token Token
+}
+
+// RandomBoard returns a board `size` cells wide, from 14 to 100, with walls
+// and sinks in random places, laid out like a standard board. The cells in
+// the middle are oob, each sink is in the corner of two walls so that robots
+// can stop on it, and each outer edge has two walls.
+func RandomBoard(r *rand.Rand, size int) (*Board, error) {
+	if size < 14 || size > 100 {
+		return nil, errors.New("invalid board size")
+	}
+	b, _ := NewBoard(size)
+	lo, hi := (size-1)/2, size/2
+	for x := lo; x <= hi; x++ {
+		for y := lo; y <= hi; y++ {
+			b.SetOOB(Position{x, y})
+		}
+	}
+
+	// Two walls on each edge, one either side of the middle, that aren't in
+	// the corners.
+	for _, d := range allDirections {
+		for _, half := range [][2]int{{1, lo - 1}, {hi, size - 3}} {
+			i := half[0] + r.Intn(half[1]-half[0]+1)
+			switch d {
+			case DirectionNorth:
+				b.AddWall(Position{i, 0}, DirectionEast)
+			case DirectionEast:
+				b.AddWall(Position{size - 1, i}, DirectionSouth)
+			case DirectionSouth:
+				b.AddWall(Position{i, size - 1}, DirectionEast)
+			case DirectionWest:
+				b.AddWall(Position{0, i}, DirectionSouth)
+			}
+		}
+	}
+
+	// Each sink goes in a cell away from the edges, the middle and the other
+	// sinks.
+	for _, s := range allShapes {
+		for _, c := range allColours {
+			var cells []Position
+			for i := range b.cells {
+				if p := b.position(i); b.sinkFits(p) {
+					cells = append(cells, p)
+				}
+			}
+			if len(cells) == 0 {
+				return nil, errors.New("no room for sinks")
+			}
+			p := cells[r.Intn(len(cells))]
+			d := Direction(r.Intn(4))
+			b.AddWall(p, d)
+			b.AddWall(p, (d+1)%4)
+			b.AddSink(Token{s, c}, p)
+		}
+	}
+	return b, nil
+}
+
+// sinkFits returns true if a sink could go in `p`, which mustn't be on the
+// edge of the board or next to an oob cell or another sink.
+func (b *Board) sinkFits(p Position) bool {
+	if p.X < 1 || p.Y < 1 || p.X > b.size-2 || p.Y > b.size-2 {
+		return false
+	}
+	for x := p.X - 1; x <= p.X+1; x++ {
+		for y := p.Y - 1; y <= p.Y+1; y++ {
+			if !b.InBounds(Position{x, y}) {
+				return false
+			}
+		}
+	}
+	for _, sp := range b.sinks {
+		if sp.X >= p.X-1 && sp.X <= p.X+1 && sp.Y >= p.Y-1 && sp.Y <= p.Y+1 {
+			return false
+		}
+	}
+	return true
+}
+
+// RandomState returns a state on board `b` with a robot of each of `colours`,
+// or one of each of the four colours if that's nil, in random cells that
+// aren't oob and don't have sinks.
+func RandomState(r *rand.Rand, b *Board, colours []Colour) (*State, error) {
+	if colours == nil {
+		colours = allColours
+	}
+	s := b.NewState()
+	for _, c := range colours {
+		var cells []Position
+		for i := range b.cells {
+			p := b.position(i)
+			if _, ok := s.at(p); ok || !b.InBounds(p) || b.hasSink(p) {
+				continue
+			}
+			cells = append(cells, p)
+		}
+		if len(cells) == 0 {
+			return nil, errors.New("no room for robots")
+		}
+		if err := s.AddRobot(cells[r.Intn(len(cells))], Robot{c}); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// hasSink returns true if there's a sink in `p`.
+func (b *Board) hasSink(p Position) bool {
+	for _, sp := range b.sinks {
+		if sp.Equal(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// RandomToken returns one of the tokens on board `b`.
+func RandomToken(r *rand.Rand, b *Board) (Token, error) {
+	tokens := b.tokens()
+	if len(tokens) == 0 {
+		return Token{}, errors.New("no tokens")
+	}
+	return tokens[r.Intn(len(tokens))], nil
+}
+
+// RandomPuzzle returns a puzzle on board `b`, with a robot of each of the four
+// colours in a random cell, and a random token. The same source of random
+// numbers, in the same state, always gives the same puzzle.
+func RandomPuzzle(r *rand.Rand, b *Board) (*Puzzle, error) {
+	s, err := RandomState(r, b, nil)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := RandomToken(r, b)
+	if err != nil {
+		return nil, err
+	}
+	return &Puzzle{b, s, tok}, nil
+}
@@ -0,0 +1,87 @@
+package ricochet
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRandomBoard(t *testing.T) {
+	for _, size := range []int{14, 15, 16, 20} {
+		for seed := int64(0); seed < 10; seed++ {
+			b, err := RandomBoard(rand.New(rand.NewSource(seed)), size)
+			if err != nil {
+				t.Fatalf("size %d: expected success, got %v", size, err)
+			}
+			if !b.Valid() {
+				t.Errorf("size %d, seed %d: expected a valid board", size, seed)
+			}
+			for tok, p := range b.sinks {
+				if !sinkInCorner(b, p) {
+					t.Errorf("size %d, seed %d: expected %v in a corner, at %v",
+						size, seed, tok, p)
+				}
+			}
+			if m := size / 2; b.InBounds(Position{m, m}) {
+				t.Errorf("size %d, seed %d: expected the middle to be oob", size, seed)
+			}
+
+			b2, _ := RandomBoard(rand.New(rand.NewSource(seed)), size)
+			if canonicalBytes(b) != canonicalBytes(b2) {
+				t.Errorf("size %d, seed %d: expected the same board", size, seed)
+			}
+		}
+	}
+
+	for _, size := range []int{0, 13, 101} {
+		if _, err := RandomBoard(rand.New(rand.NewSource(1)), size); err == nil {
+			t.Errorf("size %d: expected an error", size)
+		}
+	}
+}
+
+func TestRandomPuzzle(t *testing.T) {
+	b, _ := readTestBoard(t)
+	for seed := int64(0); seed < 20; seed++ {
+		p, err := RandomPuzzle(rand.New(rand.NewSource(seed)), b)
+		if err != nil {
+			t.Fatalf("seed %d: expected success, got %v", seed, err)
+		}
+		if len(p.Start.robots) != 4 {
+			t.Errorf("seed %d: expected 4 robots, got %v", seed, p.Start)
+		}
+		for _, pos := range p.Start.pos {
+			if !b.InBounds(pos) || b.hasSink(pos) {
+				t.Errorf("seed %d: expected no robot on a sink or oob, got %v",
+					seed, p.Start)
+			}
+		}
+		if _, ok := b.sinks[p.Token]; !ok {
+			t.Errorf("seed %d: expected a token on the board, got %v", seed, p.Token)
+		}
+
+		p2, _ := RandomPuzzle(rand.New(rand.NewSource(seed)), b)
+		if p2.Start.String() != p.Start.String() || p2.Token != p.Token {
+			t.Errorf("seed %d: expected the same puzzle", seed)
+		}
+	}
+
+	// A board with no room for robots.
+	b, _ = NewBoard(2)
+	b.SetOOB(Position{0, 0})
+	if _, err := RandomState(rand.New(rand.NewSource(1)), b, nil); err == nil {
+		t.Errorf("expected an error with no room")
+	}
+	if _, err := RandomToken(rand.New(rand.NewSource(1)), b); err == nil {
+		t.Errorf("expected an error with no tokens")
+	}
+}
+
+// sinkInCorner returns true if robots stop on `p` from two directions.
+func sinkInCorner(b *Board, p Position) bool {
+	for _, d := range allDirections {
+		if !b.canSlide(p, d) && !b.canSlide(p, (d+1)%4) {
+			return true
+		}
+	}
+	return false
+}
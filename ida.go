@@ -0,0 +1,138 @@
+package ricochet
+
+// SolveIDA returns the same number of moves as `Solve` using an iterative
+// deepening A* search. Apart from an optional transposition table of at most
+// `tableSize` states, it only needs memory proportional to the number of moves
+// in the solution. The table stops states being searched again within an
+// iteration, which saves a lot of time; a size of 0 disables it.
+func (s *State) SolveIDA(tok Token, tableSize int) []Move {
+	return s.solveWith(SolveOptions{
+		Algorithm: AlgorithmIDA,
+		TableSize: tableSize,
+	}, tok)
+}
+
+// tableEntryBytes is roughly how many bytes an entry in the transposition
+// table takes up.
+const tableEntryBytes = 48
+
+// ida does an IDA* search for a solution.
+func (sr *search) ida() ([]Move, error) {
+	is := &idaSearch{
+		search:    sr,
+		state:     sr.start.Clone(),
+		onPath:    make(map[stateKey]bool),
+		tableSize: sr.opts.TableSize,
+	}
+	if sr.opts.MaxMemory > 0 && is.tableSize > sr.opts.MaxMemory/tableEntryBytes {
+		is.tableSize = sr.opts.MaxMemory / tableEntryBytes
+	}
+
+	for limit := sr.bound; limit >= 0; {
+		// There's no solution with fewer moves than the limit.
+		if sr.deeper(limit) {
+			sr.bound = limit
+			return nil, ErrDepthExceeded
+		}
+		sr.deepen(limit)
+
+		if is.tableSize > 0 {
+			is.table = make(map[stateKey]int)
+		}
+		found, next, err := is.dfs(limit)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			path := make([]Move, len(is.path))
+			copy(path, is.path)
+			return path, nil
+		}
+		limit = next
+	}
+
+	return nil, nil
+}
+
+// idaSearch is a depth-first search that moves robots around a single state,
+// undoing each move on the way back up.
+type idaSearch struct {
+	*search
+	state  *State
+	path   []Move
+	onPath map[stateKey]bool // states on the current path, to avoid cycles
+
+	// table holds the fewest moves each state has been reached in during the
+	// current iteration. It's nil if disabled.
+	table     map[stateKey]int
+	tableSize int
+}
+
+// dfs searches from the current state for a solution within `limit` moves. If
+// there isn't one, it returns the smallest estimate of the number of moves
+// that went over the limit, or -1 if nothing did, i.e. there's no solution.
+func (is *idaSearch) dfs(limit int) (bool, int, error) {
+	s := is.state
+	g := len(is.path)
+	h := is.lowerBound(s)
+	if h < 0 {
+		return false, -1, nil
+	}
+	if f := g + h; f > limit {
+		return false, f, nil
+	}
+	if s.Solved(is.tok) {
+		return true, 0, nil
+	}
+
+	key := s.key(is.target)
+	if is.onPath[key] {
+		is.stats.StatesDeduplicated++
+		return false, -1, nil
+	}
+	if is.table != nil {
+		best, ok := is.table[key]
+		if ok && best <= g {
+			// Already searched with as many moves to spare.
+			is.stats.StatesDeduplicated++
+			return false, -1, nil
+		}
+		if ok || len(is.table) < is.tableSize {
+			is.table[key] = g
+		}
+	}
+	if err := is.expand(); err != nil {
+		return false, 0, err
+	}
+	is.onPath[key] = true
+	defer delete(is.onPath, key)
+	is.frontier(len(is.path) + 1)
+
+	next := -1
+	for i, r := range s.robots {
+		p := s.pos[i]
+		for _, d := range allDirections {
+			if !s.CanMove(p, d) {
+				continue
+			}
+
+			to := s.Move(p, d)
+			s.pos[i] = to
+			is.path = append(is.path, Move{r, p, d, to})
+
+			found, f, err := is.dfs(limit)
+			if found || err != nil {
+				return found, 0, err
+			}
+
+			is.path = is.path[:len(is.path)-1]
+			s.pos[i] = p
+
+			if f >= 0 && (next < 0 || f < next) {
+				next = f
+			}
+		}
+	}
+
+	return false, next, nil
+}
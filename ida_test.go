@@ -0,0 +1,55 @@
+package ricochet
+
+import "testing"
+
+func TestStateSolveIDA(t *testing.T) {
+	testSolve(t, func(s *State, tok Token) []Move {
+		return s.SolveIDA(tok, 1<<16)
+	})
+}
+
+func TestStateSolveIDANoTable(t *testing.T) {
+	_, s := readTestBoard(t)
+
+	for _, test := range solveTests {
+		if test.Moves > 6 {
+			continue // too slow without a table
+		}
+		if path := s.SolveIDA(test.Token, 0); len(path) != test.Moves {
+			t.Errorf("expected %v in %d moves, got %d",
+				test.Token, test.Moves, len(path))
+		}
+	}
+}
+
+func TestStateSolveIDATableSize(t *testing.T) {
+	_, s := readTestBoard(t)
+	tok := Token{ShapeHexagon, ColourBlue}
+
+	// A full table means more searching, but still a shortest solution.
+	for _, size := range []int{1, 64, 1 << 10} {
+		if path := s.SolveIDA(tok, size); len(path) != 7 {
+			t.Errorf("expected %v in 7 moves with table size %d, got %d",
+				tok, size, len(path))
+		}
+	}
+}
+
+func TestStateSolveIDAUnsolvable(t *testing.T) {
+	b, _ := NewBoard(5)
+	tok := Token{ShapeCircle, ColourRed}
+	b.AddSink(tok, Position{2, 2})
+	s := b.NewState()
+	s.AddRobot(Position{0, 0}, Robot{ColourRed})
+
+	// The red robot can only ever get to the corners.
+	if path := s.SolveIDA(tok, 1<<10); path != nil {
+		t.Errorf("expected no solution, got %v", path)
+	}
+}
+
+func BenchmarkSolveIDA(b *testing.B) {
+	benchmarkSolve(b, func(s *State, tok Token) []Move {
+		return s.SolveIDA(tok, 1<<16)
+	})
+}
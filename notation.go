@@ -0,0 +1,124 @@
+package ricochet
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Moves are written in a compact notation: the robot's colour followed by the
+// direction it moved in, e.g. `R↑ B→ Y↓`. Colours are written as `B`, `Y`,
+// `G`, `R` and `S` (silver), or as a number for any other colour. Directions
+// are written as arrows, or in ASCII as `N`, `E`, `S` and `W`, e.g. `RN BE YS`.
+
+var colourLetters = map[Colour]string{
+	ColourBlue:   "B",
+	ColourYellow: "Y",
+	ColourGreen:  "G",
+	ColourRed:    "R",
+	ColourSilver: "S",
+}
+
+var directionArrows = []string{"↑", "→", "↓", "←"}
+
+var directionLetters = []string{"N", "E", "S", "W"}
+
+func formatColour(c Colour) string {
+	if l, ok := colourLetters[c]; ok {
+		return l
+	}
+	return strconv.Itoa(int(c))
+}
+
+func formatMove(m Move, dirs []string) string {
+	if !m.Direction.Valid() {
+		return formatColour(m.Robot.Colour) + "?"
+	}
+	return formatColour(m.Robot.Colour) + dirs[m.Direction]
+}
+
+// String returns the move in notation, e.g. `R↑`.
+func (m Move) String() string {
+	return formatMove(m, directionArrows)
+}
+
+// FormatMoves returns `moves` in notation, e.g. `R↑ B→ Y↓`.
+func FormatMoves(moves []Move) string {
+	return formatMoves(moves, directionArrows)
+}
+
+// FormatMovesASCII returns `moves` in ASCII notation, e.g. `RN BE YS`.
+func FormatMovesASCII(moves []Move) string {
+	return formatMoves(moves, directionLetters)
+}
+
+func formatMoves(moves []Move, dirs []string) string {
+	sl := make([]string, len(moves))
+	for i, m := range moves {
+		sl[i] = formatMove(m, dirs)
+	}
+	return strings.Join(sl, " ")
+}
+
+// ParseMoves reads moves in either notation and replays them from this state
+// to work out where each robot starts and ends up. It doesn't check that the
+// moves are legal; see `Board.Verify` for that.
+func (s *State) ParseMoves(notation string) ([]Move, error) {
+	s = s.Clone()
+	var moves []Move
+	for i, f := range strings.Fields(notation) {
+		r, d, err := parseMove(f)
+		if err != nil {
+			return nil, fmt.Errorf("error move %d: %v", i+1, err)
+		}
+		from, ok := s.Find(r.Colour)
+		if !ok {
+			return nil, fmt.Errorf("error move %d: no robot %q", i+1,
+				formatColour(r.Colour))
+		}
+		to := s.Move(from, d)
+		s.place(from, to)
+		moves = append(moves, Move{r, from, d, to})
+	}
+	return moves, nil
+}
+
+func parseMove(m string) (Robot, Direction, error) {
+	last, size := utf8.DecodeLastRuneInString(m)
+	if size == len(m) {
+		return Robot{}, 0, errors.New("bad syntax")
+	}
+	d, err := parseDirection(string(last))
+	if err != nil {
+		return Robot{}, 0, err
+	}
+	c, err := parseColour(m[:len(m)-size])
+	if err != nil {
+		return Robot{}, 0, err
+	}
+	return Robot{c}, d, nil
+}
+
+func parseDirection(d string) (Direction, error) {
+	for i := range allDirections {
+		if d == directionArrows[i] || d == directionLetters[i] {
+			return allDirections[i], nil
+		}
+	}
+	return 0, errors.New("bad direction")
+}
+
+func parseColour(c string) (Colour, error) {
+	for col, l := range colourLetters {
+		if c == l {
+			return col, nil
+		}
+	}
+	n, err := strconv.Atoi(c)
+	if err != nil {
+		return 0, errors.New("bad colour")
+	}
+	return Colour(n), nil
+}
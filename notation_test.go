@@ -0,0 +1,99 @@
+package ricochet
+
+import "testing"
+
+func TestFormatMoves(t *testing.T) {
+	moves := []Move{
+		{Robot{ColourRed}, Position{0, 5}, DirectionNorth, Position{0, 0}},
+		{Robot{ColourBlue}, Position{0, 9}, DirectionEast, Position{9, 9}},
+		{Robot{ColourYellow}, Position{3, 0}, DirectionSouth, Position{3, 9}},
+		{Robot{ColourSilver}, Position{3, 2}, DirectionWest, Position{0, 2}},
+		{Robot{Colour(7)}, Position{3, 3}, DirectionWest, Position{0, 3}},
+	}
+
+	exp := "R↑ B→ Y↓ S← 7←"
+	if act := FormatMoves(moves); act != exp {
+		t.Errorf("expected %q, got %q", exp, act)
+	}
+	exp = "RN BE YS SW 7W"
+	if act := FormatMovesASCII(moves); act != exp {
+		t.Errorf("expected %q, got %q", exp, act)
+	}
+	exp = "G→"
+	if act := (Move{Robot: Robot{ColourGreen}, Direction: DirectionEast}).String(); act != exp {
+		t.Errorf("expected %q, got %q", exp, act)
+	}
+}
+
+func TestStateParseMoves(t *testing.T) {
+	b, _ := NewBoard(10)
+	b.AddWall(Position{1, 1}, DirectionNorth)
+	s := b.NewState()
+	s.AddRobot(Position{1, 5}, Robot{ColourRed})
+	s.AddRobot(Position{5, 5}, Robot{ColourSilver})
+
+	exp := []Move{
+		{Robot{ColourRed}, Position{1, 5}, DirectionNorth, Position{1, 1}},
+		{Robot{ColourSilver}, Position{5, 5}, DirectionWest, Position{0, 5}},
+		{Robot{ColourSilver}, Position{0, 5}, DirectionSouth, Position{0, 9}},
+		{Robot{ColourRed}, Position{1, 1}, DirectionWest, Position{0, 1}},
+	}
+	for _, n := range []string{"R↑ S← S↓ R←", "RN SW SS RW", " R↑  SW\tS↓ RW "} {
+		moves, err := s.ParseMoves(n)
+		if err != nil {
+			t.Errorf("expected success, got %v", err)
+			continue
+		}
+		if len(moves) != len(exp) {
+			t.Errorf("expected %v, got %v", exp, moves)
+			continue
+		}
+		for i := range exp {
+			if moves[i] != exp[i] {
+				t.Errorf("expected ParseMoves(%q)[%d] = %+v, got %+v",
+					n, i, exp[i], moves[i])
+			}
+		}
+	}
+
+	for _, n := range []string{"R", "↑", "R↗", "X↑", "B↑", "R↑ S"} {
+		if _, err := s.ParseMoves(n); err == nil {
+			t.Errorf("expected error parsing %q", n)
+		}
+	}
+}
+
+func TestNotationRoundTrip(t *testing.T) {
+	_, s := readTestBoard(t)
+
+	tokens := []Token{
+		{ShapeCircle, ColourYellow},
+		{ShapeTriangle, ColourGreen},
+		{ShapeDiamond, ColourBlue},
+		{ShapeDiamond, ColourGreen},
+		{ShapeHexagon, ColourYellow},
+	}
+	for _, tok := range tokens {
+		path := s.Solve(tok)
+		for _, n := range []string{FormatMoves(path), FormatMovesASCII(path)} {
+			moves, err := s.ParseMoves(n)
+			if err != nil {
+				t.Errorf("expected success parsing %q, got %v", n, err)
+				continue
+			}
+			if len(moves) != len(path) {
+				t.Errorf("expected %v, got %v", path, moves)
+				continue
+			}
+			for i := range path {
+				if moves[i] != path[i] {
+					t.Errorf("expected %q move %d to be %+v, got %+v",
+						n, i, path[i], moves[i])
+				}
+			}
+			if !checkPath(t, s, moves).Solved(tok) {
+				t.Errorf("expected %q to claim %v", n, tok)
+			}
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package ricochet
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelBFS does a breadth-first search one depth at a time, with each
+// depth's states split between workers. Where more than one state leads to
+// the same new state, the one a sequential search would have found first
+// wins, so the result is the same as `search.bfs`.
+func (sr *search) parallelBFS() ([]Move, error) {
+	workers := sr.opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	visited := newShardedSet()
+	visited.claim(sr.start.key(sr.target), settled)
+	size := nodeBytes(len(sr.start.robots))
+	sr.memory += size
+
+	frontier := []*node{{state: sr.start}}
+	for depth := 1; len(frontier) > 0; depth++ {
+		// Every state up to this depth has been checked.
+		if sr.deeper(depth) {
+			sr.bound = depth
+			return nil, ErrDepthExceeded
+		}
+		sr.deepen(depth)
+
+		level := &parallelLevel{search: sr, frontier: frontier, visited: visited}
+		results := make([]levelResult, workers)
+		var wg sync.WaitGroup
+		for w := range results {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				from := w * len(frontier) / workers
+				to := (w + 1) * len(frontier) / workers
+				results[w] = level.expand(from, to)
+			}(w)
+		}
+		wg.Wait()
+
+		// Fold the workers' results back in, in the order a sequential search
+		// would have found them.
+		sr.stats.NodesExpanded += int(level.expanded)
+		sr.stats.Depths[len(sr.stats.Depths)-1].NodesExpanded += int(level.expanded)
+		var (
+			solution *node
+			rank     int64
+		)
+		for _, r := range results {
+			if r.err != nil {
+				return nil, r.err
+			}
+			if r.solution != nil && (solution == nil || r.rank < rank) {
+				solution, rank = r.solution, r.rank
+			}
+		}
+		if solution != nil {
+			return solution.path(), nil
+		}
+
+		frontier = nil
+		for w := range results {
+			wg.Add(1)
+			go func(w int) {
+				defer wg.Done()
+				results[w].next = level.keep(results[w].next, results[w].ranks)
+			}(w)
+		}
+		wg.Wait()
+		for _, r := range results {
+			frontier = append(frontier, r.next...)
+		}
+
+		sr.stats.StatesDeduplicated += int(level.generated) - len(frontier)
+		sr.memory += size * len(frontier)
+		sr.frontier(len(frontier))
+	}
+
+	return nil, nil
+}
+
+// settled is the rank of states found at an earlier depth.
+const settled = -1
+
+// movesPerState is more than the number of moves that can be made from any
+// state, for working out ranks.
+const movesPerState = maxRobots * 4
+
+// parallelLevel is the expansion of one depth of a parallel search.
+type parallelLevel struct {
+	*search
+	frontier []*node
+	visited  *shardedSet
+
+	expanded  int64 // states expanded, updated atomically
+	generated int64 // new states found, updated atomically
+}
+
+// levelResult is what a worker found expanding part of a depth.
+type levelResult struct {
+	next     []*node
+	ranks    []int64 // ranks[i] is the rank of next[i]
+	solution *node
+	rank     int64
+	err      error
+}
+
+// expand expands frontier[from:to]. Each new state is ranked by the index of
+// the state it was found from and the index of the move, so that the lowest
+// rank is the one a sequential search would have found first.
+func (pl *parallelLevel) expand(from, to int) levelResult {
+	var (
+		r  levelResult
+		ml []Move
+	)
+	size := int64(nodeBytes(len(pl.start.robots)))
+	for i := from; i < to; i++ {
+		n := pl.frontier[i]
+		expanded := pl.stats.NodesExpanded + int(atomic.AddInt64(&pl.expanded, 1))
+		if pl.opts.MaxNodes > 0 && expanded > pl.opts.MaxNodes {
+			r.err = ErrBudgetExceeded
+			return r
+		}
+		memory := int64(pl.memory) + atomic.LoadInt64(&pl.generated)*size
+		if pl.opts.MaxMemory > 0 && memory > int64(pl.opts.MaxMemory) {
+			r.err = ErrBudgetExceeded
+			return r
+		}
+		if (i-from)%checkEvery == checkEvery-1 {
+			if r.err = pl.ctx.Err(); r.err != nil {
+				return r
+			}
+		}
+
+		ml = n.state.moves(ml[:0])
+		for j, m := range ml {
+			rank := int64(i)*movesPerState + int64(j)
+			newState := n.state.after(m)
+			child := &node{newState, n, m, n.depth + 1}
+
+			if newState.Solved(pl.tok) {
+				if r.solution == nil || rank < r.rank {
+					r.solution, r.rank = child, rank
+				}
+				continue
+			}
+			atomic.AddInt64(&pl.generated, 1)
+			if pl.visited.claim(newState.key(pl.target), rank) {
+				r.next = append(r.next, child)
+				r.ranks = append(r.ranks, rank)
+			}
+		}
+	}
+	return r
+}
+
+// keep returns the nodes in `next` that won their state, and settles them.
+func (pl *parallelLevel) keep(next []*node, ranks []int64) []*node {
+	kept := next[:0]
+	for i, n := range next {
+		if pl.visited.settle(n.state.key(pl.target), ranks[i]) {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+// shards is the number of independently locked parts of a shardedSet.
+const shards = 64
+
+// shardedSet is a set of state keys that can be used from many goroutines at
+// once. Each key holds the lowest rank it's been claimed with at the current
+// depth, or `settled`.
+type shardedSet struct {
+	shards [shards]struct {
+		sync.Mutex
+		ranks map[stateKey]int64
+	}
+}
+
+func newShardedSet() *shardedSet {
+	ss := &shardedSet{}
+	for i := range ss.shards {
+		ss.shards[i].ranks = make(map[stateKey]int64)
+	}
+	return ss
+}
+
+func shardOf(k stateKey) int {
+	h := uint32(2166136261)
+	for _, c := range k {
+		h = (h ^ uint32(c)) * 16777619
+	}
+	return int(h % shards)
+}
+
+// claim records that the state with key `k` was found with rank `rank`, and
+// returns true if it wasn't found at an earlier depth or with a lower rank.
+func (ss *shardedSet) claim(k stateKey, rank int64) bool {
+	sh := &ss.shards[shardOf(k)]
+	sh.Lock()
+	defer sh.Unlock()
+	if r, ok := sh.ranks[k]; ok && (r == settled || r <= rank) {
+		return false
+	}
+	sh.ranks[k] = rank
+	return true
+}
+
+// settle returns true if `rank` is the lowest the state with key `k` was
+// claimed with, and if so marks it as settled.
+func (ss *shardedSet) settle(k stateKey, rank int64) bool {
+	sh := &ss.shards[shardOf(k)]
+	sh.Lock()
+	defer sh.Unlock()
+	if sh.ranks[k] != rank {
+		return false
+	}
+	sh.ranks[k] = settled
+	return true
+}
@@ -0,0 +1,72 @@
+package ricochet
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestSolverParallelBFS(t *testing.T) {
+	_, s := readTestBoard(t)
+	bfs := NewSolver(SolveOptions{})
+
+	for _, test := range solveTests {
+		if test.Moves > 7 {
+			continue // too slow for the race detector
+		}
+		exp, err := bfs.Solve(context.Background(), s, test.Token)
+		if err != nil {
+			t.Fatalf("expected success, got %v", err)
+		}
+
+		// Whatever the number of workers, the solution is the one a sequential
+		// search finds.
+		for _, workers := range []int{1, 3, 8} {
+			sv := NewSolver(SolveOptions{
+				Algorithm: AlgorithmParallelBFS,
+				Workers:   workers,
+			})
+			act, err := sv.Solve(context.Background(), s, test.Token)
+			if err != nil {
+				t.Fatalf("expected success, got %v", err)
+			}
+			if FormatMoves(act.Moves) != FormatMoves(exp.Moves) {
+				t.Errorf("expected %v with %d workers to be %q, got %q", test.Token,
+					workers, FormatMoves(exp.Moves), FormatMoves(act.Moves))
+			}
+		}
+	}
+}
+
+func TestShardedSet(t *testing.T) {
+	ss := newShardedSet()
+	k1, k2 := stateKey{1, 2, 3}, stateKey{3, 2, 1}
+
+	if !ss.claim(k1, 10) || !ss.claim(k1, 5) || ss.claim(k1, 7) {
+		t.Errorf("expected only lower ranks to claim")
+	}
+	if ss.settle(k1, 10) || !ss.settle(k1, 5) {
+		t.Errorf("expected the lowest rank to settle")
+	}
+	if ss.claim(k1, 0) {
+		t.Errorf("expected settled state not to be claimed")
+	}
+	if !ss.claim(k2, 20) {
+		t.Errorf("expected claim")
+	}
+}
+
+func BenchmarkSolveParallelBFS(b *testing.B) {
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			sv := NewSolver(SolveOptions{
+				Algorithm: AlgorithmParallelBFS,
+				Workers:   workers,
+			})
+			benchmarkSolve(b, func(s *State, tok Token) []Move {
+				sol, _ := sv.Solve(context.Background(), s, tok)
+				return sol.Moves
+			})
+		})
+	}
+}
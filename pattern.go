@@ -0,0 +1,302 @@
+package ricochet
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"io"
+	"sort"
+	"sync"
+)
+
+// A PatternDB holds exact distances for the robot that claims a token, for
+// each configuration of the other robots, which block it. They're exact for
+// a relaxed puzzle in which the other robots stay put while the robot makes
+// its way to the sink, until one of them moves; after that it's only known
+// that the robot needs as many moves as `Board.lowerBounds` says. Any real
+// solution is also a solution to the relaxed puzzle, so the distances are
+// lower bounds that A* and IDA* can use, and they're usually much better ones.
+//
+// Tables are worked out as the solver needs them, and can be saved and read
+// back to warm-start solving on the same board. A PatternDB is safe to use
+// from more than one goroutine, as long as the board isn't changed.
+type PatternDB struct {
+	board *Board
+
+	mu     sync.RWMutex
+	tables map[patternKey][]uint8
+}
+
+// patternKey identifies a configuration of blocking robots, for a token.
+type patternKey struct {
+	tok    Token
+	robots int
+	cells  stateKey // sorted, as from `State.key(-1)`
+}
+
+// maxPatternTables is the most tables a PatternDB keeps. Tables needed beyond
+// that are worked out each time.
+const maxPatternTables = 1 << 16
+
+func NewPatternDB(b *Board) *PatternDB {
+	return &PatternDB{
+		board:  b,
+		tables: make(map[patternKey][]uint8),
+	}
+}
+
+// Len returns the number of tables in the database.
+func (db *PatternDB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return len(db.tables)
+}
+
+// Precompute solves for each of `tokens` from `start` with A*, or for every
+// token on the board if `tokens` is nil, adding the tables it needs.
+func (db *PatternDB) Precompute(ctx context.Context, start *State, tokens []Token) error {
+	if start.board != db.board {
+		return errors.New("state is for a different board")
+	}
+	if tokens == nil {
+		tokens = db.board.tokens()
+	}
+	sv := NewSolver(SolveOptions{Algorithm: AlgorithmAStar, Patterns: db})
+	for _, tok := range tokens {
+		if _, err := sv.Solve(ctx, start, tok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lowerBound is like `State.lowerBound`, using the database's tables.
+func (db *PatternDB) lowerBound(s *State, tok Token, bounds []int) int {
+	sink := s.board.sinks[tok]
+	best := -1
+	for i, r := range s.robots {
+		if !r.Claims(tok) {
+			continue
+		}
+		others := s.without(i)
+		k := patternKey{tok, len(others.robots), others.key(-1)}
+
+		db.mu.RLock()
+		table, ok := db.tables[k]
+		db.mu.RUnlock()
+		if !ok {
+			table = others.patternTable(sink, bounds)
+			db.mu.Lock()
+			if len(db.tables) < maxPatternTables {
+				db.tables[k] = table
+			}
+			db.mu.Unlock()
+		}
+
+		if n := table[s.board.index(s.pos[i])]; n != unreachable && (best < 0 || int(n) < best) {
+			best = int(n)
+		}
+	}
+	return best
+}
+
+// patternTable returns the fewest moves a lone robot needs to claim the token
+// with its sink in `sink` from every cell, in the relaxed puzzle where the
+// robots in this state stay put until one of them moves. From any cell the
+// robot can give up on going alone, which costs a move by another robot plus
+// at least its lower bound from `bounds`.
+func (s *State) patternTable(sink Position, bounds []int) []uint8 {
+	b := s.board
+	dist := make([]uint8, b.size*b.size)
+	for i := range dist {
+		dist[i] = unreachable
+	}
+
+	// Each bucket holds the cells that may be that many moves from the sink.
+	var buckets [][]int
+	push := func(c, n int) {
+		if n >= unreachable {
+			return
+		}
+		for len(buckets) <= n {
+			buckets = append(buckets, nil)
+		}
+		buckets[n] = append(buckets[n], c)
+	}
+	for c, n := range bounds {
+		if _, ok := s.at(b.position(c)); ok || n < 0 {
+			continue
+		}
+		if n == 0 {
+			push(c, 0)
+		} else {
+			push(c, n+1)
+		}
+	}
+
+	// This is `State.soloDistances`, starting from every cell at once.
+	steps := [4]int{-b.size, 1, b.size, -1}
+	for n := 0; n < len(buckets); n++ {
+		for _, c := range buckets[n] {
+			if dist[c] != unreachable {
+				continue
+			}
+			dist[c] = uint8(n)
+
+			p := b.position(c)
+			for _, d := range allDirections {
+				if s.CanMove(p, d) {
+					continue
+				}
+				back := d.Flip()
+				far := b.index(s.Move(p, back))
+				for from := c; from != far; {
+					from += steps[back]
+					if dist[from] == unreachable {
+						push(from, n+1)
+					}
+				}
+			}
+		}
+	}
+	return dist
+}
+
+// patternMagic starts every saved PatternDB.
+const patternMagic = "RRPDB1"
+
+// fingerprint returns a hash of this board, so that a saved PatternDB isn't
+// used with a different board.
+func (b *Board) fingerprint() uint64 {
+	h := fnv.New64a()
+	b.writeCanonical(h)
+	return h.Sum64()
+}
+
+type patternKeys []patternKey
+
+func (pk patternKeys) Len() int      { return len(pk) }
+func (pk patternKeys) Swap(i, j int) { pk[i], pk[j] = pk[j], pk[i] }
+
+func (pk patternKeys) Less(i, j int) bool {
+	a, b := pk[i], pk[j]
+	switch {
+	case a.tok.Shape != b.tok.Shape:
+		return a.tok.Shape < b.tok.Shape
+	case a.tok.Colour != b.tok.Colour:
+		return a.tok.Colour < b.tok.Colour
+	case a.robots != b.robots:
+		return a.robots < b.robots
+	}
+	for k := range a.cells {
+		if a.cells[k] != b.cells[k] {
+			return a.cells[k] < b.cells[k]
+		}
+	}
+	return false
+}
+
+// patternHeader starts each table in a saved PatternDB.
+type patternHeader struct {
+	Shape, Colour int32
+	Robots        uint8
+}
+
+// WriteTo saves the database, in a compact binary form that `ReadPatternDB`
+// reads. The same tables are always written the same way.
+func (db *PatternDB) WriteTo(w io.Writer) (int64, error) {
+	db.mu.RLock()
+	keys := make(patternKeys, 0, len(db.tables))
+	for k := range db.tables {
+		keys = append(keys, k)
+	}
+	db.mu.RUnlock()
+	sort.Sort(keys)
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	cw.Write([]byte(patternMagic))
+	binary.Write(cw, binary.BigEndian, db.board.fingerprint())
+	binary.Write(cw, binary.BigEndian, uint32(len(keys)))
+	for _, k := range keys {
+		binary.Write(cw, binary.BigEndian, patternHeader{int32(k.tok.Shape),
+			int32(k.tok.Colour), uint8(k.robots)})
+		binary.Write(cw, binary.BigEndian, k.cells[:k.robots])
+		db.mu.RLock()
+		cw.Write(db.tables[k])
+		db.mu.RUnlock()
+	}
+	if cw.err == nil {
+		cw.err = cw.w.Flush()
+	}
+	return cw.n, cw.err
+}
+
+// countingWriter counts the bytes written and holds on to the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
+
+// ReadPatternDB reads a database saved by `PatternDB.WriteTo` for board `b`.
+func ReadPatternDB(r io.Reader, b *Board) (*PatternDB, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(patternMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != patternMagic {
+		return nil, errors.New("not a pattern database")
+	}
+	var (
+		fingerprint uint64
+		count       uint32
+	)
+	if err := binary.Read(br, binary.BigEndian, &fingerprint); err != nil {
+		return nil, err
+	}
+	if fingerprint != b.fingerprint() {
+		return nil, errors.New("pattern database is for a different board")
+	}
+	if err := binary.Read(br, binary.BigEndian, &count); err != nil {
+		return nil, err
+	}
+
+	db := NewPatternDB(b)
+	for i := uint32(0); i < count; i++ {
+		var h patternHeader
+		if err := binary.Read(br, binary.BigEndian, &h); err != nil {
+			return nil, err
+		}
+		if int(h.Robots) >= maxRobots {
+			return nil, errors.New("too many robots")
+		}
+		k := patternKey{tok: Token{Shape(h.Shape), Colour(h.Colour)},
+			robots: int(h.Robots)}
+		if err := binary.Read(br, binary.BigEndian, k.cells[:k.robots]); err != nil {
+			return nil, err
+		}
+		for _, c := range k.cells[:k.robots] {
+			if int(c) >= b.size*b.size {
+				return nil, errors.New("robot is off the board")
+			}
+		}
+		table := make([]uint8, b.size*b.size)
+		if _, err := io.ReadFull(br, table); err != nil {
+			return nil, err
+		}
+		if len(db.tables) < maxPatternTables {
+			db.tables[k] = table
+		}
+	}
+	return db, nil
+}
@@ -0,0 +1,138 @@
+package ricochet
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func TestSolverPatterns(t *testing.T) {
+	b, s := readTestBoard(t)
+	for _, alg := range []Algorithm{AlgorithmAStar, AlgorithmIDA} {
+		opts := SolveOptions{Algorithm: alg, TableSize: 1 << 16}
+		plain := NewSolver(opts)
+		opts.Patterns = NewPatternDB(b)
+		sv := NewSolver(opts)
+
+		for _, test := range solveTests {
+			sol, err := sv.Solve(context.Background(), s, test.Token)
+			if err != nil {
+				t.Fatalf("algorithm %d: expected success, got %v", alg, err)
+			}
+			if len(sol.Moves) != test.Moves {
+				t.Errorf("algorithm %d: expected %v in %d moves, got %d", alg,
+					test.Token, test.Moves, len(sol.Moves))
+			}
+			if err := b.Verify(s, test.Token, sol.Moves); err != nil {
+				t.Errorf("algorithm %d: %v: %v", alg, test.Token, err)
+			}
+
+			without, _ := plain.Solve(context.Background(), s, test.Token)
+			if sol.Stats.NodesExpanded > without.Stats.NodesExpanded {
+				t.Errorf("algorithm %d: expected at most %d states expanded for %v, got %d",
+					alg, without.Stats.NodesExpanded, test.Token, sol.Stats.NodesExpanded)
+			}
+		}
+		if opts.Patterns.Len() == 0 {
+			t.Errorf("algorithm %d: expected tables to be added", alg)
+		}
+	}
+}
+
+func TestPatternDBLowerBound(t *testing.T) {
+	b, s := readTestBoard(t)
+	db := NewPatternDB(b)
+	for _, test := range solveTests {
+		bounds := b.lowerBounds(b.sinks[test.Token])
+
+		// Along a shortest solution, the bound is never more than the number
+		// of moves left, or less than the simpler bound.
+		st := s.Clone()
+		moves := s.SolveAStar(test.Token)
+		for i := 0; ; i++ {
+			h := db.lowerBound(st, test.Token, bounds)
+			if left := len(moves) - i; h > left {
+				t.Errorf("%v: expected at most %d after %d moves, got %d",
+					test.Token, left, i, h)
+			}
+			if lb := st.lowerBound(test.Token, bounds); h < lb {
+				t.Errorf("%v: expected at least %d after %d moves, got %d",
+					test.Token, lb, i, h)
+			}
+			if i == len(moves) {
+				break
+			}
+			st = st.after(moves[i])
+		}
+	}
+}
+
+func TestPatternDBReadWrite(t *testing.T) {
+	b, s := readTestBoard(t)
+	db := NewPatternDB(b)
+	tokens := []Token{{ShapeCircle, ColourRed}, {ShapeHexagon, ColourBlue}}
+	if err := db.Precompute(context.Background(), s, tokens); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := db.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("expected %d bytes written, got %d, %v", buf.Len(), n, err)
+	}
+	saved := buf.String()
+
+	db2, err := ReadPatternDB(&buf, b)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if db2.Len() != db.Len() {
+		t.Errorf("expected %d tables, got %d", db.Len(), db2.Len())
+	}
+	for k, table := range db.tables {
+		if !bytes.Equal(db2.tables[k], table) {
+			t.Errorf("expected table for %v to be read back", k)
+		}
+	}
+
+	// Writing it again gives the same bytes.
+	buf.Reset()
+	if _, err := db2.WriteTo(&buf); err != nil || buf.String() != saved {
+		t.Errorf("expected the same bytes writing again, got %v", err)
+	}
+
+	// A warm start needs no more tables.
+	before := db2.Len()
+	if err := db2.Precompute(context.Background(), s, tokens); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if db2.Len() != before {
+		t.Errorf("expected %d tables, got %d", before, db2.Len())
+	}
+
+	other, _ := NewBoard(16)
+	if _, err := ReadPatternDB(bytes.NewBufferString(saved), other); err == nil {
+		t.Errorf("expected error reading for a different board")
+	}
+	if _, err := ReadPatternDB(bytes.NewBufferString("BOARD 16\n"), b); err == nil {
+		t.Errorf("expected error reading something else")
+	}
+	if _, err := ReadPatternDB(bytes.NewBufferString(saved[:len(saved)-1]), b); err == nil {
+		t.Errorf("expected error reading a truncated database")
+	}
+}
+
+func TestPatternDBConcurrent(t *testing.T) {
+	b, s := readTestBoard(t)
+	opts := SolveOptions{Algorithm: AlgorithmAStar, Patterns: NewPatternDB(b), Workers: 4}
+	all, err := b.SolveAll(context.Background(), s, nil, opts)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	for _, test := range solveTests {
+		if len(all[test.Token].Moves) != test.Moves {
+			t.Errorf("expected %v in %d moves, got %d", test.Token, test.Moves,
+				len(all[test.Token].Moves))
+		}
+	}
+}
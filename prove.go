@@ -0,0 +1,117 @@
+package ricochet
+
+import (
+	"context"
+	"time"
+)
+
+// Proof is the result of `Solver.Prove`: a solution, or proof that there
+// isn't one.
+type Proof struct {
+	// Moves is a shortest sequence of moves that claims the token, empty if
+	// it's already claimed, or nil if the puzzle is unsolvable.
+	Moves []Move
+
+	// Unsolvable is true if every state reachable from the start was tried
+	// and none of them claims the token.
+	Unsolvable bool
+
+	// States is the number of states reachable from the start, including the
+	// start, if the puzzle is unsolvable. States where robots that can't
+	// claim the token have swapped places count once.
+	States int
+
+	// Depth is the most moves it takes to reach any of those states.
+	Depth int
+
+	// Stats describes the search for a solution, or the search of every
+	// state if there isn't one.
+	Stats SolveStats
+}
+
+// Prove is like `Solve`, but if there's no solution it makes sure of it by
+// trying every state that can be reached from `s`, however many moves it
+// takes, rather than stopping when the lower bound says the token can't be
+// claimed. The algorithm, ranking and cache are ignored; a solution is found
+// with A*. The limits apply to each search as they do to `Solve`, and if a
+// search stops early the error says why and the proof is incomplete.
+func (sv *Solver) Prove(ctx context.Context, s *State, tok Token) (*Proof, error) {
+	if s.solvable(tok) {
+		opts := sv.opts
+		opts.Algorithm, opts.Ranking, opts.Cache = AlgorithmAStar, nil, nil
+		sol, err := NewSolver(opts).Solve(ctx, s, tok)
+		if err != nil || sol.Moves != nil {
+			return &Proof{Moves: sol.Moves, Stats: sol.Stats}, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return &Proof{}, err
+	}
+
+	sr := &search{
+		ctx:     ctx,
+		opts:    sv.opts,
+		start:   s.Clone(),
+		tok:     tok,
+		target:  s.target(tok),
+		started: time.Now(),
+	}
+	return sr.exhaust()
+}
+
+// keyBytes is roughly how many bytes a key takes up in a map.
+const keyBytes = 48
+
+// exhaust does a breadth-first search of every state reachable from the
+// start, holding on to the key of each state, but only the states at the
+// current depth. It doesn't check for a solution, since A* has already proven
+// there isn't one.
+func (sr *search) exhaust() (*Proof, error) {
+	tried := map[stateKey]bool{sr.start.key(sr.target): true}
+	size := nodeBytes(len(sr.start.robots))
+	sr.memory += keyBytes + size
+
+	var ml []Move
+	layer := []*State{sr.start}
+	depth := 0
+	for {
+		var next []*State
+		for _, s := range layer {
+			if err := sr.expand(); err != nil {
+				return &Proof{Stats: sr.snapshot()}, err
+			}
+
+			ml = s.moves(ml[:0])
+			for _, m := range ml {
+				newState := s.after(m)
+				hash := newState.key(sr.target)
+				if tried[hash] {
+					sr.stats.StatesDeduplicated++
+					continue
+				}
+				tried[hash] = true
+				next = append(next, newState)
+				sr.memory += keyBytes + size
+			}
+		}
+		sr.memory -= len(layer) * size
+		if len(next) == 0 {
+			break
+		}
+
+		depth++
+		if sr.deeper(depth) {
+			return &Proof{Stats: sr.snapshot()}, ErrDepthExceeded
+		}
+		sr.deepen(depth)
+		layer = next
+		sr.frontier(len(layer))
+	}
+
+	return &Proof{
+		Unsolvable: true,
+		States:     len(tried),
+		Depth:      depth,
+		Stats:      sr.snapshot(),
+	}, nil
+}
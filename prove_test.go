@@ -0,0 +1,83 @@
+package ricochet
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSolverProve(t *testing.T) {
+	red := Token{ShapeCircle, ColourRed}
+	b, _ := NewBoard(4)
+	b.AddSink(red, Position{1, 1})
+	b.AddSink(Token{ShapeCircle, ColourGreen}, Position{2, 2})
+	b.AddWall(Position{2, 2}, DirectionNorth)
+	s := b.NewState()
+	s.AddRobot(Position{0, 0}, Robot{ColourRed})
+
+	tests := []struct {
+		Token Token
+		Why   string
+	}{
+		{red, "nothing stops the robot on the sink"},
+		{Token{ShapeCircle, ColourGreen}, "the robot can't claim the token"},
+		{Token{ShapeCircle, ColourBlue}, "the token isn't on the board"},
+	}
+	for _, test := range tests {
+		p, err := NewSolver(SolveOptions{}).Prove(context.Background(), s, test.Token)
+		if err != nil {
+			t.Fatalf("%s: expected success, got %v", test.Why, err)
+		}
+		// The robot can only get to the corners.
+		if p.Moves != nil || !p.Unsolvable || p.States != 4 || p.Depth != 2 {
+			t.Errorf("%s: expected 4 states 2 moves apart, got %+v", test.Why, p)
+		}
+	}
+
+	// The robot can get to a cell the sink's walls would stop it on, but
+	// never to the cell it would have to come from.
+	b.AddWall(Position{1, 1}, DirectionNorth)
+	s2 := b.NewState()
+	s2.AddRobot(Position{0, 0}, Robot{ColourRed})
+	if s2.lowerBound(red, b.lowerBounds(Position{1, 1})) < 0 {
+		t.Fatalf("expected a lower bound for %v", s2)
+	}
+	p, err := NewSolver(SolveOptions{}).Prove(context.Background(), s2, red)
+	if err != nil || !p.Unsolvable || p.States != 4 {
+		t.Errorf("expected 4 states, got %+v, %v", p, err)
+	}
+}
+
+func TestSolverProveSolvable(t *testing.T) {
+	_, s := readTestBoard(t)
+	sv := NewSolver(SolveOptions{Algorithm: AlgorithmIDA})
+	for _, test := range solveTests {
+		if test.Moves > 7 {
+			continue
+		}
+		p, err := sv.Prove(context.Background(), s, test.Token)
+		if err != nil {
+			t.Fatalf("%v: expected success, got %v", test.Token, err)
+		}
+		if p.Unsolvable || len(p.Moves) != test.Moves {
+			t.Errorf("%v: expected %d moves, got %+v", test.Token, test.Moves, p)
+		}
+		checkPath(t, s, p.Moves)
+	}
+}
+
+func TestSolverProveBudget(t *testing.T) {
+	_, s := readTestBoard(t)
+	tok := Token{ShapeCircle, ColourBlue}
+	p, _ := s.Find(ColourBlue)
+	i, _ := s.at(p)
+	s = s.without(i)
+
+	// Every state the other robots can get to has to be tried.
+	proof, err := NewSolver(SolveOptions{MaxNodes: 1000}).Prove(context.Background(), s, tok)
+	if err != ErrBudgetExceeded {
+		t.Errorf("expected %v, got %v", ErrBudgetExceeded, err)
+	}
+	if proof.Unsolvable || proof.Stats.NodesExpanded != 1001 {
+		t.Errorf("expected an incomplete proof, got %+v", proof)
+	}
+}
@@ -0,0 +1,171 @@
+package ricochet
+
+// Ranking picks between solutions with the same number of moves. The best one
+// moves the fewest robots, then has the lowest total weight, then changes
+// direction the fewest times from one move to the next.
+type Ranking struct {
+	// Weights is what each move of a robot of a given colour costs. Colours
+	// that aren't in the map cost nothing.
+	Weights map[Colour]int
+}
+
+// Cost is the cost of a solution under a ranking. Costs are compared field by
+// field, in order.
+type Cost struct {
+	Moves            int
+	Robots           int // robots that moved
+	Weight           int
+	DirectionChanges int
+}
+
+// Less returns true if `c` is a better cost than `c2`.
+func (c Cost) Less(c2 Cost) bool {
+	switch {
+	case c.Moves != c2.Moves:
+		return c.Moves < c2.Moves
+	case c.Robots != c2.Robots:
+		return c.Robots < c2.Robots
+	case c.Weight != c2.Weight:
+		return c.Weight < c2.Weight
+	}
+	return c.DirectionChanges < c2.DirectionChanges
+}
+
+// Cost returns the cost of `moves` under this ranking.
+func (rk *Ranking) Cost(moves []Move) Cost {
+	c := Cost{Moves: len(moves)}
+	var moved []Colour
+	for i, m := range moves {
+		c.Weight += rk.Weights[m.Robot.Colour]
+		if i > 0 && m.Direction != moves[i-1].Direction {
+			c.DirectionChanges++
+		}
+		seen := false
+		for _, col := range moved {
+			seen = seen || col == m.Robot.Colour
+		}
+		if !seen {
+			moved = append(moved, m.Robot.Colour)
+		}
+	}
+	c.Robots = len(moved)
+	return c
+}
+
+// rankedKey identifies a state while searching with a ranking. Whether two
+// states are the same depends on which robot is where, which robots have
+// moved and the direction of the last move, since each of those changes what
+// the rest of a solution costs. The robots that can't claim the token aren't
+// interchangeable like they are in a `stateKey`, as they may have different
+// weights.
+type rankedKey struct {
+	cells [maxRobots]uint16
+	moved uint8 // bit i is set if robot i has moved
+	last  int8  // the direction of the last move, or -1
+}
+
+// rankedNode is a state found by a ranked search and the cost of reaching it.
+type rankedNode struct {
+	*node
+	key  rankedKey
+	cost Cost
+}
+
+// ranked finds how many moves a solution needs with `search.astar`, then does
+// a breadth-first search one depth at a time, keeping only the cheapest way of
+// reaching each state at each depth and dropping states whose lower bound is
+// too high. Since what the rest of a solution costs only depends on the state,
+// the cheapest solution found is the best.
+func (sr *search) ranked() ([]Move, error) {
+	moves, err := sr.astar()
+	if err != nil || moves == nil {
+		return moves, err
+	}
+	limit := len(moves)
+
+	rk := sr.opts.Ranking
+	start := &rankedNode{node: &node{state: sr.start}}
+	start.key = sr.start.rankedKey(0, -1)
+	visited := map[rankedKey]bool{start.key: true}
+	size := nodeBytes(len(sr.start.robots))
+	sr.memory += size
+
+	var ml []Move
+	layer := []*rankedNode{start}
+	for depth := 1; depth <= limit; depth++ {
+		var (
+			next  []*rankedNode
+			index = make(map[rankedKey]int) // index in `next`
+			best  *rankedNode
+		)
+		for _, n := range layer {
+			if err := sr.expand(); err != nil {
+				return nil, err
+			}
+
+			ml = n.state.moves(ml[:0])
+			for _, m := range ml {
+				i, _ := n.state.at(m.From)
+				newState := n.state.after(m)
+				child := &rankedNode{
+					node: &node{newState, n.node, m, depth},
+					key:  newState.rankedKey(n.key.moved|1<<uint(i), int8(m.Direction)),
+					cost: n.cost,
+				}
+				child.cost.Moves++
+				child.cost.Weight += rk.Weights[m.Robot.Colour]
+				if n.key.last >= 0 && Direction(n.key.last) != m.Direction {
+					child.cost.DirectionChanges++
+				}
+				if n.key.moved&(1<<uint(i)) == 0 {
+					child.cost.Robots++
+				}
+
+				if child.state.Solved(sr.tok) {
+					if best == nil || child.cost.Less(best.cost) {
+						best = child
+					}
+					continue
+				}
+				if h := newState.lowerBound(sr.tok, sr.bounds); h < 0 || depth+h > limit {
+					continue
+				}
+				if visited[child.key] {
+					sr.stats.StatesDeduplicated++
+					continue
+				}
+				if j, ok := index[child.key]; ok {
+					sr.stats.StatesDeduplicated++
+					if child.cost.Less(next[j].cost) {
+						next[j] = child
+					}
+					continue
+				}
+				index[child.key] = len(next)
+				next = append(next, child)
+				sr.memory += size
+			}
+		}
+		if best != nil {
+			return best.path(), nil
+		}
+
+		for _, n := range next {
+			visited[n.key] = true
+		}
+		layer = next
+		sr.frontier(len(layer))
+	}
+
+	return moves, nil
+}
+
+// rankedKey returns the key for this state, given which robots have moved and
+// the direction of the last move.
+func (s *State) rankedKey(moved uint8, last int8) rankedKey {
+	k := rankedKey{moved: moved, last: last}
+	for i, p := range s.pos {
+		k.cells[i] = uint16(s.board.index(p))
+	}
+	return k
+}
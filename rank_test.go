@@ -0,0 +1,82 @@
+package ricochet
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRankingCost(t *testing.T) {
+	_, s := readTestBoard(t)
+	rk := &Ranking{Weights: map[Colour]int{ColourBlue: 3, ColourRed: 1}}
+	for _, test := range []struct {
+		moves string
+		cost  Cost
+	}{
+		{"", Cost{}},
+		{"B↑", Cost{1, 1, 3, 0}},
+		{"B↑ B↑ R↑", Cost{3, 2, 7, 0}},
+		{"B↑ Y→ B↓ B↓", Cost{4, 2, 9, 2}},
+		{"G← Y← R↓ B←", Cost{4, 4, 4, 2}},
+	} {
+		moves, err := s.ParseMoves(test.moves)
+		if err != nil {
+			t.Errorf("%q: %v", test.moves, err)
+			continue
+		}
+		if cost := rk.Cost(moves); cost != test.cost {
+			t.Errorf("%q: expected %+v, got %+v", test.moves, test.cost, cost)
+		}
+	}
+}
+
+func TestCostLess(t *testing.T) {
+	costs := []Cost{
+		{3, 1, 9, 9},
+		{4, 1, 0, 0},
+		{4, 2, 0, 0},
+		{4, 2, 1, 0},
+		{4, 2, 1, 1},
+	}
+	for i, c := range costs {
+		for j, c2 := range costs {
+			if c.Less(c2) != (i < j) {
+				t.Errorf("expected %+v < %+v to be %v", c, c2, i < j)
+			}
+		}
+	}
+}
+
+func TestSolverRanking(t *testing.T) {
+	_, s := readTestBoard(t)
+	for _, rk := range []*Ranking{
+		{},
+		{Weights: map[Colour]int{ColourBlue: 1, ColourGreen: 2}},
+		{Weights: map[Colour]int{ColourYellow: 5, ColourRed: -1}},
+	} {
+		sv := NewSolver(SolveOptions{Ranking: rk})
+		for _, test := range solveTests {
+			if test.Moves > 7 {
+				continue
+			}
+			sol, err := sv.Solve(context.Background(), s, test.Token)
+			if err != nil {
+				t.Fatalf("expected success, got %v", err)
+			}
+			if err := s.board.Verify(s, test.Token, sol.Moves); err != nil {
+				t.Errorf("%v: %s: %v", test.Token, FormatMoves(sol.Moves), err)
+				continue
+			}
+
+			// No shortest solution, in any order, is better.
+			cost := rk.Cost(sol.Moves)
+			for _, moves := range s.SolveAll(test.Token, 0) {
+				for _, o := range s.orderings(moves) {
+					if c := rk.Cost(o); c.Less(cost) {
+						t.Errorf("%v: expected %s with %+v to be no better than %s with %+v",
+							test.Token, FormatMoves(o), c, FormatMoves(sol.Moves), cost)
+					}
+				}
+			}
+		}
+	}
+}
@@ -20,8 +20,9 @@ import (
 // `size` is a number from 1 - 100.
 // `position` is a 0-indexed coordinated in the form `col,row`, e.g. `4,5`.
 // `direction` is a number from 0 - 3, where 0 is north, 1 is east, etc.
-// `colour` is a number from 0 - 3 for `SINK`, and any number for `ROBOT`.
-// `shape` is a number from 0 - 3.
+// `colour` is a number from 0 - 3 for `SINK`, or 4 for the vortex, and any
+// number for `ROBOT`.
+// `shape` is a number from 0 - 3, or 4 for the vortex, which is colour 4.
 func ReadBoard(r *bufio.Reader) (*Board, *State, error) {
 	var (
 		board *Board
@@ -147,7 +148,7 @@ func readBoardSink(tl []string, b *Board) error {
 		return err
 	}
 	col := Colour(c)
-	if !col.ValidForToken() {
+	if !col.ValidForToken() && col != ColourMulti {
 		return errors.New("bad colour")
 	}
 	s, err := strconv.Atoi(tl[2])
@@ -155,7 +156,7 @@ func readBoardSink(tl []string, b *Board) error {
 		return err
 	}
 	shape := Shape(s)
-	if !shape.Valid() {
+	if !shape.Valid() && shape != ShapeVortex {
 		return errors.New("bad shape")
 	}
 	return b.AddSink(Token{shape, col}, pos)
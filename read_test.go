@@ -167,6 +167,22 @@ SINK 1,1 2 2`
 	if err == nil {
 		t.Errorf("expected error")
 	}
+
+	s = `BOARD 10
+SINK 1,1 4 4`
+	_, _, err = ReadBoard(bufio.NewReader(strings.NewReader(s)))
+	if err != nil {
+		t.Errorf("expected success, got %v", err)
+	}
+
+	// The vortex is the only multi-colour token.
+	for _, line := range []string{"SINK 1,1 4 0", "SINK 1,1 0 4", "SINK 1,1 5 5"} {
+		s = "BOARD 10\n" + line
+		_, _, err = ReadBoard(bufio.NewReader(strings.NewReader(s)))
+		if err == nil {
+			t.Errorf("%s: expected error", line)
+		}
+	}
 }
 
 func TestReadBoardRobot(t *testing.T) {
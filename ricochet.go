@@ -30,6 +30,9 @@ const (
 	ShapeTriangle Shape = 1
 	ShapeDiamond  Shape = 2
 	ShapeHexagon  Shape = 3
+
+	// The vortex, which is the shape of the multi-colour token:
+	ShapeVortex Shape = 4
 )
 
 func (s Shape) Valid() bool {
@@ -46,6 +49,9 @@ const (
 	ColourGreen  Colour = 2
 	ColourRed    Colour = 3
 
+	// The colour of the multi-colour token, which any robot may claim:
+	ColourMulti Colour = 4
+
 	// Additional robot colours:
 	ColourSilver Colour = 10
 )
@@ -61,6 +67,15 @@ type Token struct {
 	Colour Colour
 }
 
+// TokenVortex is the multi-colour token.
+var TokenVortex = Token{ShapeVortex, ColourMulti}
+
+// Valid returns true if the token is one of the sixteen coloured tokens or
+// the vortex.
+func (t Token) Valid() bool {
+	return t == TokenVortex || t.Shape.Valid() && t.Colour.ValidForToken()
+}
+
 type Position struct {
 	X int
 	Y int
@@ -84,6 +99,8 @@ func (p Position) Next(dir Direction) Position {
 	return p
 }
 
+// Block is kept for compatibility. Boards now keep track of walls and
+// oob positions in a flat slice of cells.
 type Block struct {
 	oob   bool
 	walls map[Direction]bool
@@ -97,14 +114,29 @@ type Robot struct {
 	Colour Colour
 }
 
+// Claims returns true if the robot may claim `tok` by stopping on its sink. A
+// coloured token may only be claimed by the robot of the same colour, so the
+// silver robot can only ever claim the multi-colour token.
+func (r Robot) Claims(tok Token) bool {
+	if tok.Colour == ColourMulti {
+		return true
+	}
+	return tok.Colour.ValidForToken() && r.Colour == tok.Colour
+}
+
+// Move is a robot sliding from one position in a direction until it's stopped
+// by a wall, a robot or the edge of the board.
 type Move struct {
-	Robot    Robot
-	Position Position
+	Robot     Robot
+	From      Position
+	Direction Direction
+	Position  Position // where the robot ends up
 }
 
 type State struct {
 	board  *Board
-	robots map[Position]Robot
+	robots []Robot    // ordered by colour
+	pos    []Position // pos[i] is the position of robots[i]
 	path   []Move
 }
 
@@ -113,39 +145,66 @@ func (s *State) AddRobot(pos Position, robot Robot) error {
 		return errors.New("position out of bounds")
 	}
 
-	for p, r := range s.robots {
-		if p.Equal(pos) {
+	if len(s.robots) == maxRobots {
+		return errors.New("too many robots")
+	}
+
+	i := 0
+	for j, r := range s.robots {
+		if s.pos[j].Equal(pos) {
 			return errors.New("position already has a robot")
 		}
 		if r.Colour == robot.Colour {
 			return errors.New("robot already added")
 		}
+		if r.Colour < robot.Colour {
+			i = j + 1
+		}
 	}
 
-	s.robots[pos] = robot
+	s.robots = append(s.robots, Robot{})
+	copy(s.robots[i+1:], s.robots[i:])
+	s.robots[i] = robot
+	s.pos = append(s.pos, Position{})
+	copy(s.pos[i+1:], s.pos[i:])
+	s.pos[i] = pos
 	return nil
 }
 
-// CanMove returns true if a robot can move from the given position in the given
-// direction.
-func (s *State) CanMove(pos Position, dir Direction) bool {
-	next := pos.Next(dir)
-
-	// Next block is OOB...
-	if !s.board.InBounds(next) {
-		return false
+// Find returns the position of the robot with colour `c`.
+func (s *State) Find(c Colour) (Position, bool) {
+	for i, r := range s.robots {
+		if r.Colour == c {
+			return s.pos[i], true
+		}
 	}
+	return Position{}, false
+}
 
-	// There's a wall in the way...
-	if s.board.blocks[pos].walls[dir] {
-		return false
+// Robots returns the robots in this state, by colour.
+func (s *State) Robots() []Robot {
+	return append([]Robot(nil), s.robots...)
+}
+
+// at returns the index of the robot in `pos`.
+func (s *State) at(pos Position) (int, bool) {
+	for i, p := range s.pos {
+		if p.Equal(pos) {
+			return i, true
+		}
 	}
-	if s.board.blocks[next].walls[dir.Flip()] {
+	return 0, false
+}
+
+// CanMove returns true if a robot can move from the given position in the given
+// direction.
+func (s *State) CanMove(pos Position, dir Direction) bool {
+	if !s.board.canSlide(pos, dir) {
 		return false
 	}
 
 	// There's a robot in the way...
-	if _, ok := s.robots[next]; ok {
+	if _, ok := s.at(pos.Next(dir)); ok {
 		return false
 	}
 
@@ -155,76 +214,166 @@ func (s *State) CanMove(pos Position, dir Direction) bool {
 // Move returns the position a robot would end up in if it started in `pos` and
 // moved in direction `dir`.
 func (s *State) Move(pos Position, dir Direction) Position {
-	for {
-		if !s.CanMove(pos, dir) {
-			return pos
+	if !dir.Valid() || !s.board.onBoard(pos) {
+		return pos
+	}
+
+	// Look up where the robot would stop if it were alone on the board, then
+	// stop it short of the nearest robot in the way.
+	stop := s.board.position(s.board.stops[s.board.index(pos)*4+int(dir)])
+	for _, p := range s.pos {
+		switch dir {
+		case DirectionNorth:
+			if p.X == pos.X && p.Y < pos.Y && p.Y >= stop.Y {
+				stop.Y = p.Y + 1
+			}
+		case DirectionEast:
+			if p.Y == pos.Y && p.X > pos.X && p.X <= stop.X {
+				stop.X = p.X - 1
+			}
+		case DirectionSouth:
+			if p.X == pos.X && p.Y > pos.Y && p.Y <= stop.Y {
+				stop.Y = p.Y - 1
+			}
+		case DirectionWest:
+			if p.Y == pos.Y && p.X < pos.X && p.X >= stop.X {
+				stop.X = p.X + 1
+			}
 		}
-		pos = pos.Next(dir)
+	}
+	return stop
+}
+
+// place moves the robot in `from` to `to`.
+func (s *State) place(from, to Position) {
+	if i, ok := s.at(from); ok {
+		s.pos[i] = to
 	}
 }
 
 // Clone returns a state with the same robot positions as this one.
 func (s *State) Clone() *State {
-	n := s.board.NewState()
-	for p, r := range s.robots {
-		n.robots[p] = r
+	return &State{
+		board:  s.board,
+		robots: append([]Robot(nil), s.robots...),
+		pos:    append([]Position(nil), s.pos...),
 	}
-	return n
 }
 
 const minRobots = 4
 
+// Each cell on the board is a bitmask of the walls around it, indexed by
+// direction, and whether it's oob.
+const cellOOB = 1 << 4
+
 type Board struct {
-	size   int                // the width or height of the board
-	blocks map[Position]Block // positions of blocks of interest
-	sinks  map[Token]Position // positions and types of tokens on the board
+	size  int                // the width or height of the board
+	cells []uint8            // walls and oob for each position, by index
+	sinks map[Token]Position // positions and types of tokens on the board
+
+	// stops holds the index of the cell a robot would stop in if it started in
+	// each cell and moved in each direction, with no other robots on the
+	// board. It's indexed by cell index * 4 + direction.
+	stops []int
 }
 
 func NewBoard(size int) (*Board, error) {
 	if size < 1 || size > 100 {
 		return nil, errors.New("invalid board size")
 	}
-	return &Board{
-		size:   size,
-		blocks: make(map[Position]Block),
-		sinks:  make(map[Token]Position),
-	}, nil
+	b := &Board{
+		size:  size,
+		cells: make([]uint8, size*size),
+		sinks: make(map[Token]Position),
+		stops: make([]int, size*size*4),
+	}
+	// Every row and column crosses the diagonal.
+	for i := 0; i < size; i++ {
+		b.updateStops(Position{i, i})
+	}
+	return b, nil
 }
 
 func (b *Board) NewState() *State {
-	return &State{b, make(map[Position]Robot), nil}
+	return &State{board: b}
+}
+
+// index returns a number from 0 to size*size-1 identifying `p`.
+func (b *Board) index(p Position) int {
+	return p.Y*b.size + p.X
+}
+
+// position returns the position identified by index `i`.
+func (b *Board) position(i int) Position {
+	return Position{i % b.size, i / b.size}
+}
+
+func (b *Board) wall(pos Position, dir Direction) bool {
+	return b.cells[b.index(pos)]&(1<<uint(dir)) != 0
+}
+
+// canSlide returns true if a robot could move from the given position in the
+// given direction if there were no other robots on the board.
+func (b *Board) canSlide(pos Position, dir Direction) bool {
+	next := pos.Next(dir)
+
+	// Next block is OOB...
+	if !b.InBounds(next) {
+		return false
+	}
+
+	// There's a wall in the way...
+	if b.onBoard(pos) && b.wall(pos, dir) {
+		return false
+	}
+	if b.wall(next, dir.Flip()) {
+		return false
+	}
+
+	return true
 }
 
-func (b *Board) getBlock(pos Position) Block {
-	block, ok := b.blocks[pos]
-	if !ok {
-		return NewBlock()
+// updateStops works out where robots stop for every cell in the same row and
+// column as `p`. It must be called whenever a wall or oob cell is added.
+func (b *Board) updateStops(p Position) {
+	for i := 0; i < b.size; i++ {
+		b.updateStop(Position{i, p.Y}, DirectionEast)
+		b.updateStop(Position{i, p.Y}, DirectionWest)
+		b.updateStop(Position{p.X, i}, DirectionNorth)
+		b.updateStop(Position{p.X, i}, DirectionSouth)
 	}
-	return block
 }
 
-func (b *Board) InBounds(p Position) bool {
+func (b *Board) updateStop(p Position, dir Direction) {
+	stop := p
+	for b.canSlide(stop, dir) {
+		stop = stop.Next(dir)
+	}
+	b.stops[b.index(p)*4+int(dir)] = b.index(stop)
+}
+
+// onBoard returns true if `p` is within the edges of the board, whether or
+// not it's oob.
+func (b *Board) onBoard(p Position) bool {
 	if p.X < 0 || p.X >= b.size {
 		return false
 	}
 	if p.Y < 0 || p.Y >= b.size {
 		return false
 	}
-	for pos, block := range b.blocks {
-		if pos.Equal(p) && block.oob {
-			return false
-		}
-	}
 	return true
 }
 
+func (b *Board) InBounds(p Position) bool {
+	return b.onBoard(p) && b.cells[b.index(p)]&cellOOB == 0
+}
+
 func (b *Board) SetOOB(pos Position) error {
 	if !b.InBounds(pos) {
 		return errors.New("position already oob")
 	}
-	block := b.getBlock(pos)
-	block.oob = true
-	b.blocks[pos] = block
+	b.cells[b.index(pos)] |= cellOOB
+	b.updateStops(pos)
 	return nil
 }
 
@@ -232,19 +381,24 @@ func (b *Board) AddWall(pos Position, dir Direction) error {
 	if !b.InBounds(pos) {
 		return errors.New("wall out of bounds")
 	}
+	if !dir.Valid() {
+		return errors.New("invalid direction")
+	}
 
-	block := b.getBlock(pos)
-	if block.walls[dir] {
+	if b.wall(pos, dir) {
 		return errors.New("duplicate wall")
 	}
 
-	block.walls[dir] = true
-	b.blocks[pos] = block
+	b.cells[b.index(pos)] |= 1 << uint(dir)
+	b.updateStops(pos)
 
 	return nil
 }
 
 func (b *Board) AddSink(token Token, pos Position) error {
+	if !token.Valid() {
+		return errors.New("invalid token")
+	}
 	if _, ok := b.sinks[token]; ok {
 		return errors.New("token is already on board")
 	}
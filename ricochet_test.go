@@ -1,6 +1,10 @@
 package ricochet
 
-import "testing"
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
 
 func TestDirectionFlip(t *testing.T) {
 	if f := DirectionNorth.Flip(); f != DirectionSouth {
@@ -69,6 +73,15 @@ func TestStateAddRobot(t *testing.T) {
 	if err := s.AddRobot(Position{0, 1}, r); err != nil {
 		t.Errorf("expected success, got %v", err)
 	}
+
+	for i := 2; i < maxRobots; i++ {
+		if err := s.AddRobot(Position{0, i}, Robot{Colour(10 + i)}); err != nil {
+			t.Errorf("expected success, got %v", err)
+		}
+	}
+	if err := s.AddRobot(Position{0, 9}, Robot{ColourSilver}); err == nil {
+		t.Errorf("expected error")
+	}
 }
 
 type canMoveTest struct {
@@ -258,3 +271,41 @@ func TestValid(t *testing.T) {
 		t.Errorf("expected valid")
 	}
 }
+
+func TestStateMoveMatchesCanMove(t *testing.T) {
+	_, s := readTestBoard(t)
+
+	// Moving should be the same as stepping until the robot can't go further.
+	for x := 0; x < s.board.size; x++ {
+		for y := 0; y < s.board.size; y++ {
+			start := Position{x, y}
+			if !s.board.InBounds(start) {
+				continue
+			}
+			for _, d := range allDirections {
+				exp := start
+				for s.CanMove(exp, d) {
+					exp = exp.Next(d)
+				}
+				if act := s.Move(start, d); !act.Equal(exp) {
+					t.Errorf("expected Move(%v, %d) = %v, got %v",
+						start, d, exp, act)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkStateMove(b *testing.B) {
+	_, s, err := ReadBoard(bufio.NewReader(strings.NewReader(testBoard)))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for x := 0; x < 16; x++ {
+			s.Move(Position{x, x}, allDirections[i%4])
+		}
+	}
+}
@@ -1,67 +1,156 @@
 package ricochet
 
 import (
-	"fmt"
-	"sort"
+	"context"
 	"strconv"
+	"strings"
 )
 
+// Solved returns true if a robot that may claim `tok` is sitting on its sink.
+func (s *State) Solved(tok Token) bool {
+	sinkPos, ok := s.board.sinks[tok]
+	if !ok {
+		return false
+	}
+	i, ok := s.at(sinkPos)
+	return ok && s.robots[i].Claims(tok)
+}
+
+// solvable returns false if `tok` can't be claimed from this state no matter
+// how the robots are moved, i.e. the token isn't on the board or there's no
+// robot that may claim it.
+func (s *State) solvable(tok Token) bool {
+	if _, ok := s.board.sinks[tok]; !ok {
+		return false
+	}
+	for _, r := range s.robots {
+		if r.Claims(tok) {
+			return true
+		}
+	}
+	return false
+}
+
+// Solve returns the shortest sequence of moves that ends with a robot that may
+// claim `tok` on its sink, or nil if there is no such sequence. If the token
+// is already claimed the sequence is empty.
 func (s *State) Solve(tok Token) []Move {
-	sinkPos := s.board.sinks[tok]
-	queue := []*State{s}
-	tried := map[string]bool{s.String(): true}
+	return s.solveWith(SolveOptions{}, tok)
+}
+
+func (s *State) solveWith(opts SolveOptions, tok Token) []Move {
+	sol, _ := NewSolver(opts).Solve(context.Background(), s, tok)
+	return sol.Moves
+}
+
+// bfs does a breadth-first search for a solution.
+func (sr *search) bfs() ([]Move, error) {
+	queue := []*node{{state: sr.start}}
+	tried := map[stateKey]bool{sr.start.key(sr.target): true}
+	size := nodeBytes(len(sr.start.robots))
+	sr.memory += size
 
+	var ml []Move
 	depth := 0
 	for len(queue) > 0 {
-		qs := queue[0]
+		n := queue[0]
+		queue[0] = nil
 		queue = queue[1:]
 
-		if len(qs.path) > 1 {
-			if _, ok := s.robots[sinkPos]; ok {
-				return qs.path
+		if n.depth >= depth {
+			// Every state up to this depth has been checked.
+			depth = n.depth + 1
+			if sr.deeper(depth) {
+				sr.bound = depth
+				return nil, ErrDepthExceeded
 			}
+			sr.deepen(depth)
 		}
 
-		if len(qs.path) > depth {
-			depth = len(qs.path)
-			fmt.Printf("Trying depth %d (queue %d)\n", depth, len(queue))
+		if err := sr.expand(); err != nil {
+			return nil, err
 		}
 
-		for p, r := range s.robots {
-			for _, d := range allDirections {
-				if s.CanMove(p, d) {
-					newState := qs.Clone()
-					next := qs.Move(p, d)
-
-					delete(newState.robots, p)
-					newState.robots[next] = r
-					newState.path = append(qs.path, Move{r, next})
-
-					// If we've already tried this state, ignore.
-					hash := newState.String()
-					if tried[hash] {
-						continue
-					}
-
-					tried[hash] = true
-					queue = append(queue, newState)
-				}
+		ml = n.state.moves(ml[:0])
+		for _, m := range ml {
+			newState := n.state.after(m)
+			child := &node{newState, n, m, depth}
+
+			if newState.Solved(sr.tok) {
+				return child.path(), nil
+			}
+
+			// If we've already tried this state, ignore.
+			hash := newState.key(sr.target)
+			if tried[hash] {
+				sr.stats.StatesDeduplicated++
+				continue
 			}
+
+			tried[hash] = true
+			queue = append(queue, child)
+			sr.memory += size
 		}
+		sr.frontier(len(queue))
 	}
 
-	return nil
+	return nil, nil
 }
 
-func (s *State) String() string {
-	var sl []int
-	for p := range s.robots {
-		sl = append(sl, p.X*s.board.size+p.Y)
+// maxRobots is the most robots a state can have, so that keys are a fixed
+// size.
+const maxRobots = 8
+
+// stateKey identifies a state while solving. It holds the index of the cell
+// each robot is in, with the robot that may claim the token first. The other
+// robots can't claim it, so they're interchangeable: their cells are sorted
+// so that states where they've swapped places have the same key.
+type stateKey [maxRobots]uint16
+
+// target returns the index of the only robot that may claim `tok`, or -1 if
+// any robot may.
+func (s *State) target(tok Token) int {
+	if tok.Colour == ColourMulti {
+		return -1
 	}
-	sort.Ints(sl)
-	var str string
-	for _, i := range sl {
-		str = str + strconv.Itoa(i) + ","
+	for i, r := range s.robots {
+		if r.Claims(tok) {
+			return i
+		}
+	}
+	return -1
+}
+
+// key returns the key for this state when solving for the robot with index
+// `target`, as returned by `State.target`.
+func (s *State) key(target int) stateKey {
+	var k stateKey
+	n := 0
+	if target >= 0 {
+		k[0] = uint16(s.board.index(s.pos[target]))
+		n = 1
+	}
+	for i, p := range s.pos {
+		if i == target {
+			continue
+		}
+		c := uint16(s.board.index(p))
+		j := n
+		for ; j > 0 && (target < 0 || j > 1) && k[j-1] > c; j-- {
+			k[j] = k[j-1]
+		}
+		k[j] = c
+		n++
+	}
+	return k
+}
+
+// String returns the robots and their positions, e.g. `B3,2 R13,11`.
+func (s *State) String() string {
+	sl := make([]string, len(s.robots))
+	for i, r := range s.robots {
+		sl[i] = formatColour(r.Colour) + strconv.Itoa(s.pos[i].X) + "," +
+			strconv.Itoa(s.pos[i].Y)
 	}
-	return str
+	return strings.Join(sl, " ")
 }
@@ -0,0 +1,258 @@
+package ricochet
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+// testBoard is a full 16x16 board with all sixteen coloured sinks and four
+// robots.
+const testBoard = `BOARD 16
+OOB 7,7
+OOB 7,8
+OOB 8,7
+OOB 8,8
+WALL 3,0 1
+WALL 9,0 1
+WALL 5,1 1
+WALL 6,1 2
+WALL 12,1 0
+WALL 12,1 1
+WALL 9,2 1
+WALL 9,2 2
+WALL 2,3 0
+WALL 2,3 1
+WALL 8,3 1
+WALL 4,4 1
+WALL 5,4 0
+WALL 2,5 1
+WALL 2,5 2
+WALL 7,5 1
+WALL 7,5 2
+WALL 10,6 1
+WALL 11,6 2
+WALL 0,9 2
+WALL 11,9 1
+WALL 12,8 2
+WALL 15,9 2
+WALL 3,10 1
+WALL 3,10 2
+WALL 5,10 2
+WALL 10,10 1
+WALL 10,10 2
+WALL 5,11 0
+WALL 5,11 1
+WALL 14,11 2
+WALL 2,12 2
+WALL 2,12 3
+WALL 4,12 2
+WALL 14,12 1
+WALL 3,13 1
+WALL 10,14 1
+WALL 11,14 2
+WALL 3,15 1
+WALL 13,15 1
+SINK 6,1 0 0
+SINK 12,1 3 0
+SINK 9,2 2 1
+SINK 1,3 1 1
+SINK 9,3 0 2
+SINK 5,4 2 2
+SINK 2,5 3 3
+SINK 11,6 1 3
+SINK 12,9 0 3
+SINK 3,10 0 1
+SINK 10,10 1 2
+SINK 5,11 2 3
+SINK 2,12 1 0
+SINK 14,12 3 1
+SINK 4,13 3 2
+SINK 11,14 2 0
+ROBOT 3,2 0
+ROBOT 10,5 1
+ROBOT 5,1 2
+ROBOT 13,11 3`
+
+func readTestBoard(t *testing.T) (*Board, *State) {
+	b, s, err := ReadBoard(bufio.NewReader(strings.NewReader(testBoard)))
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	return b, s
+}
+
+// checkPath replays `path` from `s`, failing the test if any move isn't one a
+// robot could make, and returns the resulting state.
+func checkPath(t *testing.T, s *State, path []Move) *State {
+	s = s.Clone()
+	for i, m := range path {
+		if from, ok := s.Find(m.Robot.Colour); !ok || !from.Equal(m.From) {
+			t.Fatalf("move %d: no robot %v at %v", i, m.Robot, m.From)
+		}
+		if !s.CanMove(m.From, m.Direction) {
+			t.Fatalf("move %d: robot %v can't move %d", i, m.Robot, m.Direction)
+		}
+		if to := s.Move(m.From, m.Direction); !to.Equal(m.Position) {
+			t.Fatalf("move %d: expected robot %v to end at %v, got %v",
+				i, m.Robot, m.Position, to)
+		}
+		s.place(m.From, m.Position)
+	}
+	return s
+}
+
+type solveTest struct {
+	Token Token
+	Moves int
+}
+
+// solveTests gives the length of the shortest solution for every token on
+// testBoard.
+var solveTests = []solveTest{
+	{Token{ShapeCircle, ColourBlue}, 5},
+	{Token{ShapeCircle, ColourYellow}, 4},
+	{Token{ShapeCircle, ColourGreen}, 4},
+	{Token{ShapeCircle, ColourRed}, 7},
+	{Token{ShapeTriangle, ColourBlue}, 1},
+	{Token{ShapeTriangle, ColourYellow}, 10},
+	{Token{ShapeTriangle, ColourGreen}, 3},
+	{Token{ShapeTriangle, ColourRed}, 4},
+	{Token{ShapeDiamond, ColourBlue}, 6},
+	{Token{ShapeDiamond, ColourYellow}, 1},
+	{Token{ShapeDiamond, ColourGreen}, 5},
+	{Token{ShapeDiamond, ColourRed}, 3},
+	{Token{ShapeHexagon, ColourBlue}, 7},
+	{Token{ShapeHexagon, ColourYellow}, 6},
+	{Token{ShapeHexagon, ColourGreen}, 7},
+	{Token{ShapeHexagon, ColourRed}, 9},
+}
+
+// testSolve checks that `solve` finds a shortest solution for every token on
+// testBoard.
+func testSolve(t *testing.T, solve func(*State, Token) []Move) {
+	b, s := readTestBoard(t)
+	if len(solveTests) != len(b.sinks) {
+		t.Fatalf("expected a test for each of %d tokens", len(b.sinks))
+	}
+
+	for _, test := range solveTests {
+		path := solve(s, test.Token)
+		if len(path) != test.Moves {
+			t.Errorf("expected %v in %d moves, got %d",
+				test.Token, test.Moves, len(path))
+			continue
+		}
+		end := checkPath(t, s, path)
+		if !end.Solved(test.Token) {
+			t.Errorf("expected %v to be claimed", test.Token)
+		}
+		if i, _ := end.at(b.sinks[test.Token]); end.robots[i].Colour != test.Token.Colour {
+			t.Errorf("expected %v to end with robot %d on the sink",
+				test.Token, test.Token.Colour)
+		}
+	}
+}
+
+func TestStateSolve(t *testing.T) {
+	testSolve(t, (*State).Solve)
+}
+
+func TestStateSolveGoal(t *testing.T) {
+	b, _ := NewBoard(5)
+	red := Token{ShapeCircle, ColourRed}
+	b.AddSink(red, Position{0, 0})
+	b.AddSink(TokenVortex, Position{4, 0})
+
+	// Only the red robot may claim the red token, so the silver robot sitting
+	// on its sink doesn't count.
+	s := b.NewState()
+	s.AddRobot(Position{0, 0}, Robot{ColourSilver})
+	s.AddRobot(Position{0, 4}, Robot{ColourRed})
+	if s.Solved(red) {
+		t.Errorf("expected silver robot not to claim %v", red)
+	}
+	if path := s.Solve(red); len(path) != 2 {
+		t.Errorf("expected Solve(%v) in 2 moves, got %v", red, path)
+	}
+
+	// Any robot may claim the multi-colour token, silver included.
+	if path := s.Solve(TokenVortex); len(path) != 1 {
+		t.Errorf("expected Solve(%v) in 1 move, got %v", TokenVortex, path)
+	} else if path[0].Robot.Colour != ColourSilver {
+		t.Errorf("expected silver robot to claim %v", TokenVortex)
+	}
+
+	// A token that's already claimed needs no moves.
+	s = b.NewState()
+	s.AddRobot(Position{0, 0}, Robot{ColourRed})
+	if path := s.Solve(red); path == nil || len(path) != 0 {
+		t.Errorf("expected empty solution, got %v", path)
+	}
+
+	// There's no solution without a robot that may claim the token, or if the
+	// token isn't on the board.
+	s = b.NewState()
+	s.AddRobot(Position{0, 4}, Robot{ColourBlue})
+	s.AddRobot(Position{4, 4}, Robot{ColourSilver})
+	if path := s.Solve(red); path != nil {
+		t.Errorf("expected no solution, got %v", path)
+	}
+	if path := s.Solve(Token{ShapeHexagon, ColourBlue}); path != nil {
+		t.Errorf("expected no solution, got %v", path)
+	}
+}
+
+func TestStateKey(t *testing.T) {
+	b, _ := NewBoard(10)
+	newState := func(pos ...Position) *State {
+		s := b.NewState()
+		for i, p := range pos {
+			s.AddRobot(p, Robot{allColours[i]})
+		}
+		return s
+	}
+	s := newState(Position{1, 1}, Position{2, 2}, Position{3, 3})
+	target := s.target(Token{ShapeCircle, ColourGreen})
+
+	// Swapping robots that can't claim the token doesn't matter...
+	if s.key(target) != newState(Position{2, 2}, Position{1, 1}, Position{3, 3}).key(target) {
+		t.Errorf("expected same key")
+	}
+	// ...but swapping the one that can does.
+	if s.key(target) == newState(Position{1, 1}, Position{3, 3}, Position{2, 2}).key(target) {
+		t.Errorf("expected different key")
+	}
+	// Any robot can claim the multi-colour token.
+	target = s.target(TokenVortex)
+	if s.key(target) != newState(Position{3, 3}, Position{1, 1}, Position{2, 2}).key(target) {
+		t.Errorf("expected same key")
+	}
+	if s.key(target) == newState(Position{3, 3}, Position{1, 1}, Position{2, 3}).key(target) {
+		t.Errorf("expected different key")
+	}
+}
+
+func TestStateString(t *testing.T) {
+	b, _ := NewBoard(10)
+	s := b.NewState()
+	s.AddRobot(Position{3, 4}, Robot{ColourRed})
+	s.AddRobot(Position{1, 2}, Robot{ColourBlue})
+
+	if exp, act := "B1,2 R3,4", s.String(); act != exp {
+		t.Errorf("expected %q, got %q", exp, act)
+	}
+}
+
+func BenchmarkStateKey(b *testing.B) {
+	_, s, err := ReadBoard(bufio.NewReader(strings.NewReader(testBoard)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	target := s.target(Token{ShapeCircle, ColourRed})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.key(target)
+	}
+}
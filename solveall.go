@@ -0,0 +1,183 @@
+package ricochet
+
+import (
+	"context"
+	"time"
+)
+
+// SolveAll returns every distinct shortest sequence of moves that claims
+// `tok`, or the first `max` of them if `max` isn't 0. See `Solver.SolveAll`.
+func (s *State) SolveAll(tok Token, max int) [][]Move {
+	sol, _ := NewSolver(SolveOptions{}).SolveAll(context.Background(), s, tok, max)
+	return sol.All
+}
+
+// SolveAll is like `Solve`, but finds every distinct shortest solution, or the
+// first `max` of them if `max` isn't 0. Solutions that only differ in the
+// order of moves that don't affect each other, i.e. where every robot makes
+// the same moves either way, count as one. Solutions are ordered move by move,
+// by robot colour and then direction, and each is the first of its orderings.
+// If the search stops early, the solutions found so far are kept.
+func (sv *Solver) SolveAll(ctx context.Context, s *State, tok Token, max int) (*Solution, error) {
+	sol, err := sv.Solve(ctx, s, tok)
+	if err != nil || sol.Moves == nil {
+		return sol, err
+	}
+	if len(sol.Moves) == 0 {
+		sol.All = [][]Move{sol.Moves}
+		return sol, nil
+	}
+
+	// Carry on from where the search for the number of moves left off.
+	sr := sv.newSearch(ctx, s, tok)
+	sr.stats = sol.Stats
+	sr.started = time.Now().Add(-sol.Stats.Elapsed)
+	sr.depthStart = time.Now()
+	if n := len(sol.Stats.Depths); n > 0 {
+		sr.depthStart = sr.depthStart.Add(-sol.Stats.Depths[n-1].Elapsed)
+	}
+
+	e := &enumeration{
+		search: sr,
+		state:  sr.start.Clone(),
+		max:    max,
+		failed: make(map[stateKey]int),
+		seen:   make(map[string]bool),
+	}
+	if sr.opts.MaxMemory > 0 {
+		e.tableSize = sr.opts.MaxMemory / tableEntryBytes
+	}
+	_, err = e.dfs(len(sol.Moves))
+
+	sol.All = e.all
+	if len(e.all) > 0 && sv.opts.Ranking == nil {
+		sol.Moves = e.all[0]
+	}
+	sol.Stats = sr.snapshot()
+	return sol, err
+}
+
+// enumeration is a depth-first search for every solution with a known number
+// of moves. It moves robots around a single state like `idaSearch`, trying
+// moves in order, so the first ordering of a solution it finds is the first
+// in order.
+type enumeration struct {
+	*search
+	state *State
+	path  []Move
+	max   int
+	hits  int // solutions found, including orderings of earlier ones
+
+	// failed holds the most moves each state is known not to be solvable
+	// in. It holds at most `tableSize` states, or any number if that's 0.
+	failed    map[stateKey]int
+	tableSize int
+
+	seen map[string]bool // every ordering of the solutions found
+	all  [][]Move
+}
+
+// dfs searches from the current state for solutions in exactly `left` moves.
+// It returns true once it has found as many as it needs.
+func (e *enumeration) dfs(left int) (bool, error) {
+	s := e.state
+	if left == 0 {
+		if s.Solved(e.tok) {
+			return e.found(), nil
+		}
+		return false, nil
+	}
+	if h := s.lowerBound(e.tok, e.bounds); h < 0 || h > left {
+		return false, nil
+	}
+
+	key := s.key(e.target)
+	if f, ok := e.failed[key]; ok && f >= left {
+		e.stats.StatesDeduplicated++
+		return false, nil
+	}
+	if err := e.expand(); err != nil {
+		return false, err
+	}
+	e.frontier(len(e.path) + 1)
+
+	hits := e.hits
+	for i, r := range s.robots {
+		p := s.pos[i]
+		for _, d := range allDirections {
+			if !s.CanMove(p, d) {
+				continue
+			}
+
+			to := s.Move(p, d)
+			s.pos[i] = to
+			e.path = append(e.path, Move{r, p, d, to})
+
+			done, err := e.dfs(left - 1)
+			if done || err != nil {
+				return done, err
+			}
+
+			e.path = e.path[:len(e.path)-1]
+			s.pos[i] = p
+		}
+	}
+
+	if e.hits == hits && (e.tableSize == 0 || len(e.failed) < e.tableSize) {
+		e.failed[key] = left
+	}
+	return false, nil
+}
+
+// found records the current path as a solution, unless it's an ordering of
+// one already found, and returns true if there are enough solutions.
+func (e *enumeration) found() bool {
+	e.hits++
+	if e.seen[FormatMovesASCII(e.path)] {
+		return false
+	}
+	path := make([]Move, len(e.path))
+	copy(path, e.path)
+	e.all = append(e.all, path)
+	for _, o := range e.start.orderings(path) {
+		e.seen[FormatMovesASCII(o)] = true
+	}
+	return e.max > 0 && len(e.all) >= e.max
+}
+
+// orderings returns `moves`, followed by every other sequence of moves from
+// this state that can be made by repeatedly swapping neighbouring moves that
+// don't affect each other.
+func (s *State) orderings(moves []Move) [][]Move {
+	all := [][]Move{moves}
+	seen := map[string]bool{FormatMovesASCII(moves): true}
+	for i := 0; i < len(all); i++ {
+		ml := all[i]
+		st := s.Clone()
+		for j := 0; j+1 < len(ml); j++ {
+			if st.commute(ml[j], ml[j+1]) {
+				o := make([]Move, len(ml))
+				copy(o, ml)
+				o[j], o[j+1] = o[j+1], o[j]
+				if k := FormatMovesASCII(o); !seen[k] {
+					seen[k] = true
+					all = append(all, o)
+				}
+			}
+			st.place(ml[j].From, ml[j].Position)
+		}
+	}
+	return all
+}
+
+// commute returns true if making move `a` then move `b` from this state can be
+// done the other way around, with each robot making the same move.
+func (s *State) commute(a, b Move) bool {
+	if a.Robot == b.Robot {
+		return false
+	}
+	if !s.Move(b.From, b.Direction).Equal(b.Position) {
+		return false
+	}
+	return s.after(b).Move(a.From, a.Direction).Equal(a.Position)
+}
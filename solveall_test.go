@@ -0,0 +1,181 @@
+package ricochet
+
+import (
+	"context"
+	"testing"
+)
+
+// lessMoves returns true if `a` comes before `b` in the order solutions are
+// returned in. Robots are ordered by colour.
+func lessMoves(a, b []Move) bool {
+	for i := range a {
+		switch {
+		case a[i].Robot.Colour != b[i].Robot.Colour:
+			return a[i].Robot.Colour < b[i].Robot.Colour
+		case a[i].Direction != b[i].Direction:
+			return a[i].Direction < b[i].Direction
+		}
+	}
+	return false
+}
+
+// allShortest returns every sequence of exactly `n` moves from `s` that claims
+// `tok`, trying every move.
+func allShortest(s *State, tok Token, n int) map[string]bool {
+	all := make(map[string]bool)
+	var (
+		path []Move
+		try  func(s *State)
+	)
+	try = func(s *State) {
+		if len(path) == n {
+			if s.Solved(tok) {
+				all[FormatMovesASCII(path)] = true
+			}
+			return
+		}
+		for _, m := range s.moves(nil) {
+			path = append(path, m)
+			try(s.after(m))
+			path = path[:len(path)-1]
+		}
+	}
+	try(s)
+	return all
+}
+
+func TestStateSolveAll(t *testing.T) {
+	_, s := readTestBoard(t)
+	for _, test := range []struct {
+		tok       Token
+		moves     int
+		solutions int
+	}{
+		{Token{ShapeCircle, ColourBlue}, 5, 2},
+		{Token{ShapeCircle, ColourYellow}, 4, 1},
+		{Token{ShapeTriangle, ColourGreen}, 3, 1},
+		{Token{ShapeDiamond, ColourGreen}, 5, 1},
+		{Token{ShapeDiamond, ColourRed}, 3, 1},
+		{Token{ShapeHexagon, ColourYellow}, 6, 2},
+		{Token{ShapeHexagon, ColourRed}, 9, 6},
+	} {
+		all := s.SolveAll(test.tok, 0)
+		if len(all) != test.solutions {
+			t.Errorf("%v: expected %d solutions, got %d", test.tok,
+				test.solutions, len(all))
+			continue
+		}
+
+		orderings := make(map[string]int)
+		for i, moves := range all {
+			if len(moves) != test.moves {
+				t.Errorf("%v: expected %d moves, got %s", test.tok, test.moves,
+					FormatMoves(moves))
+			}
+			if err := s.board.Verify(s, test.tok, moves); err != nil {
+				t.Errorf("%v: %s: %v", test.tok, FormatMoves(moves), err)
+			}
+			if i > 0 && !lessMoves(all[i-1], moves) {
+				t.Errorf("%v: expected %s before %s", test.tok,
+					FormatMoves(moves), FormatMoves(all[i-1]))
+			}
+			for _, o := range s.orderings(moves) {
+				if lessMoves(o, moves) {
+					t.Errorf("%v: expected %s before its ordering %s", test.tok,
+						FormatMoves(moves), FormatMoves(o))
+				}
+				if j, ok := orderings[FormatMovesASCII(o)]; ok {
+					t.Errorf("%v: solutions %d and %d are orderings of each other",
+						test.tok, j, i)
+				}
+				orderings[FormatMovesASCII(o)] = i
+			}
+		}
+
+		// Every shortest solution is an ordering of one of them.
+		if test.moves > 5 {
+			continue
+		}
+		want := allShortest(s, test.tok, test.moves)
+		if len(orderings) != len(want) {
+			t.Errorf("%v: expected %d shortest solutions, got %d", test.tok,
+				len(want), len(orderings))
+		}
+		for k := range want {
+			if _, ok := orderings[k]; !ok {
+				t.Errorf("%v: missing solution %s", test.tok, k)
+			}
+		}
+	}
+}
+
+func TestStateSolveAllMax(t *testing.T) {
+	_, s := readTestBoard(t)
+	tok := Token{ShapeHexagon, ColourRed}
+	all := s.SolveAll(tok, 0)
+	for _, max := range []int{1, 2, 5} {
+		some := s.SolveAll(tok, max)
+		if len(some) != max {
+			t.Errorf("expected %d solutions, got %d", max, len(some))
+			continue
+		}
+		for i := range some {
+			if FormatMoves(some[i]) != FormatMoves(all[i]) {
+				t.Errorf("expected solution %d to be %s, got %s", i,
+					FormatMoves(all[i]), FormatMoves(some[i]))
+			}
+		}
+	}
+}
+
+func TestStateSolveAllGoal(t *testing.T) {
+	b, _ := NewBoard(5)
+	red := Token{ShapeCircle, ColourRed}
+	b.AddSink(red, Position{0, 0})
+
+	s := b.NewState()
+	s.AddRobot(Position{0, 0}, Robot{ColourRed})
+	if all := s.SolveAll(red, 0); len(all) != 1 || len(all[0]) != 0 {
+		t.Errorf("expected one empty solution, got %v", all)
+	}
+
+	s = b.NewState()
+	s.AddRobot(Position{0, 4}, Robot{ColourBlue})
+	if all := s.SolveAll(red, 0); all != nil {
+		t.Errorf("expected no solutions, got %v", all)
+	}
+}
+
+func TestSolverSolveAllBudget(t *testing.T) {
+	_, s := readTestBoard(t)
+	tok := Token{ShapeHexagon, ColourRed}
+	full, err := NewSolver(SolveOptions{Algorithm: AlgorithmAStar}).SolveAll(
+		context.Background(), s, tok, 0)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+
+	sv := NewSolver(SolveOptions{
+		Algorithm: AlgorithmAStar,
+		MaxNodes:  full.Stats.NodesExpanded - 1,
+	})
+	sol, err := sv.SolveAll(context.Background(), s, tok, 0)
+	if err != ErrBudgetExceeded {
+		t.Errorf("expected %v, got %v", ErrBudgetExceeded, err)
+	}
+
+	// The solutions found before it stopped are still in order.
+	if len(sol.All) > len(full.All) {
+		t.Fatalf("expected at most %d solutions, got %d", len(full.All),
+			len(sol.All))
+	}
+	for i := range sol.All {
+		if FormatMoves(sol.All[i]) != FormatMoves(full.All[i]) {
+			t.Errorf("expected solution %d to be %s, got %s", i,
+				FormatMoves(full.All[i]), FormatMoves(sol.All[i]))
+		}
+	}
+	if sol.LowerBound != 9 {
+		t.Errorf("expected lower bound 9, got %d", sol.LowerBound)
+	}
+}
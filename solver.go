@@ -0,0 +1,367 @@
+package ricochet
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrDepthExceeded means there's no solution within the maximum depth.
+	ErrDepthExceeded = errors.New("maximum depth exceeded")
+
+	// ErrBudgetExceeded means the search ran out of nodes or memory.
+	ErrBudgetExceeded = errors.New("budget exceeded")
+)
+
+// Algorithm is a way of searching for a solution.
+type Algorithm int
+
+const (
+	// AlgorithmBFS is a breadth-first search. It's simple but holds every
+	// state it finds in memory.
+	AlgorithmBFS Algorithm = 0
+
+	// AlgorithmAStar is an A* search, which uses a lower bound on the number
+	// of moves left to explore far fewer states than a breadth-first search.
+	AlgorithmAStar Algorithm = 1
+
+	// AlgorithmIDA is an iterative deepening A* search, which only needs
+	// memory proportional to the number of moves, plus a bounded
+	// transposition table.
+	AlgorithmIDA Algorithm = 2
+
+	// AlgorithmParallelBFS is a breadth-first search that splits each depth
+	// between several workers.
+	AlgorithmParallelBFS Algorithm = 3
+
+	// AlgorithmBidirectional is a breadth-first search that also searches
+	// backward from the sink for the robot that may claim the token, which
+	// suits puzzles where that robot does most of the moving.
+	AlgorithmBidirectional Algorithm = 4
+)
+
+// SolveOptions configures a Solver. The zero value is a breadth-first search
+// with no limits.
+type SolveOptions struct {
+	Algorithm Algorithm
+
+	// MaxDepth is the most moves a solution may have. 0 means no limit.
+	MaxDepth int
+
+	// MaxNodes is the most states that may be expanded. 0 means no limit.
+	MaxNodes int
+
+	// MaxMemory is roughly the most bytes the search may hold on to. 0 means
+	// no limit. For AlgorithmIDA it limits the size of the transposition
+	// table instead of stopping the search.
+	MaxMemory int
+
+	// TableSize is the most states the transposition table holds for
+	// AlgorithmIDA. 0 disables the table.
+	TableSize int
+
+	// Workers is the number of goroutines AlgorithmParallelBFS uses. 0 means
+	// one for each CPU.
+	Workers int
+
+	// Ranking, if set, picks the best of the shortest solutions. Algorithm is
+	// then ignored: an A* search finds the number of moves, then a
+	// breadth-first search finds the best solution. It has to tell apart
+	// states that it otherwise wouldn't, so it explores many more of them.
+	Ranking *Ranking
+
+	// Patterns, if set, gives AlgorithmAStar and AlgorithmIDA better lower
+	// bounds, and is added to as they search.
+	Patterns *PatternDB
+
+	// Cache, if set, is checked for a solution before searching, and given
+	// every search's solution, or lack of one, unless it stops early. Any
+	// algorithm's solution may be found there. It isn't used with a ranking.
+	Cache SolutionCache
+
+	// Progress, if set, is called with the statistics so far each time the
+	// search moves on to a greater depth.
+	Progress func(SolveStats)
+}
+
+// SolveStats describes the work done by a search.
+type SolveStats struct {
+	NodesExpanded      int // states whose moves were tried
+	StatesDeduplicated int // states found again and ignored
+	MaxFrontier        int // most states waiting to be expanded at once
+	Elapsed            time.Duration
+
+	// Depths breaks the search down by depth, which is the number of moves
+	// for a breadth-first search, or the estimated number of moves for A* and
+	// IDA*. The search has proven there's no solution with fewer moves than
+	// the last depth.
+	Depths []DepthStats
+}
+
+// DepthStats describes the work done by a search at one depth.
+type DepthStats struct {
+	Depth         int
+	NodesExpanded int
+	Elapsed       time.Duration
+}
+
+// Solution is the result of solving a puzzle.
+type Solution struct {
+	// Moves is a shortest sequence of moves that claims the token, empty if
+	// it's already claimed, or nil if there's no solution.
+	Moves []Move
+
+	// LowerBound is the fewest moves a solution could have, as proven by the
+	// search so far. It's still set if the search stopped early.
+	LowerBound int
+
+	// All holds every distinct shortest sequence of moves, in order, if the
+	// solution was found by `Solver.SolveAll`. Moves is the first of them,
+	// unless there's a ranking, in which case it's the best.
+	All [][]Move
+
+	Stats SolveStats
+
+	// Cached is true if the solution came from the cache, without a search.
+	Cached bool
+}
+
+// Solver solves puzzles with a given set of options. It's safe to use from
+// more than one goroutine, as long as the boards aren't changed.
+type Solver struct {
+	opts SolveOptions
+}
+
+func NewSolver(opts SolveOptions) *Solver {
+	return &Solver{opts}
+}
+
+// Solve searches for a shortest sequence of moves from `s` that ends with a
+// robot that may claim `tok` on its sink. If there's no solution the moves are
+// nil and the error is nil. If the search stops early because the context is
+// done or a limit is reached, the error says why, and the solution still holds
+// the lower bound proven so far.
+//
+// Every algorithm tries moves in the same order, by robot colour and then
+// direction, and breaks ties the same way every time, so a puzzle always gets
+// the same solution from a given set of options.
+func (sv *Solver) Solve(ctx context.Context, s *State, tok Token) (*Solution, error) {
+	if err := ctx.Err(); err != nil {
+		return &Solution{}, err
+	}
+	if !s.solvable(tok) {
+		return &Solution{}, nil
+	}
+	if s.Solved(tok) {
+		return &Solution{Moves: []Move{}}, nil
+	}
+
+	cache := sv.opts.Cache
+	if sv.opts.Ranking != nil {
+		cache = nil
+	}
+	var key CacheKey
+	if cache != nil {
+		key = NewCacheKey(s, tok)
+		if moves, ok := cache.Get(key); ok {
+			return sv.cached(moves)
+		}
+	}
+
+	sr := sv.newSearch(ctx, s, tok)
+	if sr.bound < 0 {
+		return &Solution{}, nil
+	}
+
+	var (
+		moves []Move
+		err   error
+	)
+	switch {
+	case sv.opts.Ranking != nil:
+		moves, err = sr.ranked()
+	case sv.opts.Algorithm == AlgorithmAStar:
+		moves, err = sr.astar()
+	case sv.opts.Algorithm == AlgorithmIDA:
+		moves, err = sr.ida()
+	case sv.opts.Algorithm == AlgorithmParallelBFS:
+		moves, err = sr.parallelBFS()
+	case sv.opts.Algorithm == AlgorithmBidirectional:
+		moves, err = sr.bidirectional()
+	default:
+		moves, err = sr.bfs()
+	}
+
+	sol := &Solution{Moves: moves, LowerBound: sr.bound, Stats: sr.snapshot()}
+	if moves != nil {
+		sol.LowerBound = len(moves)
+	}
+	if cache != nil && err == nil {
+		cache.Put(key, moves)
+	}
+	return sol, err
+}
+
+// cached returns a solution from the cache, as a search with these options
+// would have.
+func (sv *Solver) cached(moves []Move) (*Solution, error) {
+	sol := &Solution{Moves: moves, Cached: true}
+	if moves == nil {
+		return sol, nil
+	}
+	sol.LowerBound = len(moves)
+	if sv.opts.MaxDepth > 0 && len(moves) > sv.opts.MaxDepth {
+		sol.Moves = nil
+		return sol, ErrDepthExceeded
+	}
+	return sol, nil
+}
+
+func (sv *Solver) newSearch(ctx context.Context, s *State, tok Token) *search {
+	sr := &search{
+		ctx:     ctx,
+		opts:    sv.opts,
+		start:   s.Clone(),
+		tok:     tok,
+		target:  s.target(tok),
+		bounds:  s.board.lowerBounds(s.board.sinks[tok]),
+		started: time.Now(),
+	}
+	sr.bound = sr.lowerBound(sr.start)
+	return sr
+}
+
+// lowerBound returns the fewest moves needed to claim the token from `s`, or -1
+// if it can't be claimed, using the pattern database if there is one.
+func (sr *search) lowerBound(s *State) int {
+	if db := sr.opts.Patterns; db != nil && db.board == s.board {
+		return db.lowerBound(s, sr.tok, sr.bounds)
+	}
+	return s.lowerBound(sr.tok, sr.bounds)
+}
+
+// search holds what every algorithm needs while searching for a solution.
+type search struct {
+	ctx    context.Context
+	opts   SolveOptions
+	start  *State
+	tok    Token
+	target int   // the robot that may claim the token; see `State.target`
+	bounds []int // see `Board.lowerBounds`
+
+	bound  int // the fewest moves a solution could have
+	memory int // roughly the number of bytes held
+
+	stats      SolveStats
+	started    time.Time
+	depthStart time.Time // when the search moved on to the current depth
+}
+
+// checkEvery is how many states are expanded between checks of the context.
+const checkEvery = 1024
+
+// expand is called before each state is expanded, and returns an error if the
+// search must stop.
+func (sr *search) expand() error {
+	sr.stats.NodesExpanded++
+	if n := len(sr.stats.Depths); n > 0 {
+		sr.stats.Depths[n-1].NodesExpanded++
+	}
+	if sr.opts.MaxNodes > 0 && sr.stats.NodesExpanded > sr.opts.MaxNodes {
+		return ErrBudgetExceeded
+	}
+	if sr.opts.MaxMemory > 0 && sr.memory > sr.opts.MaxMemory {
+		return ErrBudgetExceeded
+	}
+	if sr.stats.NodesExpanded%checkEvery == 0 {
+		return sr.ctx.Err()
+	}
+	return nil
+}
+
+// deepen is called when the search moves on to `depth`, having proven there's
+// no solution with fewer moves.
+func (sr *search) deepen(depth int) {
+	now := time.Now()
+	if n := len(sr.stats.Depths); n > 0 {
+		sr.stats.Depths[n-1].Elapsed = now.Sub(sr.depthStart)
+	}
+	sr.depthStart = now
+	sr.stats.Depths = append(sr.stats.Depths, DepthStats{Depth: depth})
+	if depth > sr.bound {
+		sr.bound = depth
+	}
+	if sr.opts.Progress != nil {
+		sr.opts.Progress(sr.snapshot())
+	}
+}
+
+// frontier is called with the number of states waiting to be expanded.
+func (sr *search) frontier(n int) {
+	if n > sr.stats.MaxFrontier {
+		sr.stats.MaxFrontier = n
+	}
+}
+
+// snapshot returns a copy of the statistics so far.
+func (sr *search) snapshot() SolveStats {
+	now := time.Now()
+	stats := sr.stats
+	stats.Elapsed = now.Sub(sr.started)
+	stats.Depths = append([]DepthStats(nil), sr.stats.Depths...)
+	if n := len(stats.Depths); n > 0 {
+		stats.Depths[n-1].Elapsed = now.Sub(sr.depthStart)
+	}
+	return stats
+}
+
+// deeper returns true if a solution `g` moves long would be beyond the
+// maximum depth.
+func (sr *search) deeper(g int) bool {
+	return sr.opts.MaxDepth > 0 && g > sr.opts.MaxDepth
+}
+
+// node is a state found by a search, and how it was found.
+type node struct {
+	state  *State
+	parent *node
+	move   Move // the move from the parent's state to this one
+	depth  int
+}
+
+// nodeBytes is roughly how many bytes a node and its key take up when holding
+// `n` robots.
+func nodeBytes(n int) int {
+	return 176 + 24*n
+}
+
+// path returns the moves that lead to this node.
+func (n *node) path() []Move {
+	path := make([]Move, n.depth)
+	for ; n.parent != nil; n = n.parent {
+		path[n.depth-1] = n.move
+	}
+	return path
+}
+
+// moves appends to `ml` every move any robot can make from this state, by robot
+// then direction.
+func (s *State) moves(ml []Move) []Move {
+	for i, r := range s.robots {
+		p := s.pos[i]
+		for _, d := range allDirections {
+			if s.CanMove(p, d) {
+				ml = append(ml, Move{r, p, d, s.Move(p, d)})
+			}
+		}
+	}
+	return ml
+}
+
+// after returns the state after making move `m`.
+func (s *State) after(m Move) *State {
+	n := s.Clone()
+	n.place(m.From, m.Position)
+	return n
+}
@@ -0,0 +1,204 @@
+package ricochet
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+var allAlgorithms = []Algorithm{AlgorithmBFS, AlgorithmAStar, AlgorithmIDA,
+	AlgorithmParallelBFS, AlgorithmBidirectional}
+
+// solveExpanded returns the number of states expanded solving for `tok`.
+func solveExpanded(t *testing.T, s *State, tok Token, alg Algorithm) int {
+	sol, err := NewSolver(SolveOptions{Algorithm: alg}).Solve(
+		context.Background(), s, tok)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	return sol.Stats.NodesExpanded
+}
+
+func TestSolverSolve(t *testing.T) {
+	for _, alg := range allAlgorithms {
+		sv := NewSolver(SolveOptions{Algorithm: alg, TableSize: 1 << 16})
+		testSolve(t, func(s *State, tok Token) []Move {
+			sol, err := sv.Solve(context.Background(), s, tok)
+			if err != nil {
+				t.Errorf("algorithm %d: expected success, got %v", alg, err)
+			}
+			if sol.LowerBound != len(sol.Moves) {
+				t.Errorf("algorithm %d: expected lower bound %d, got %d",
+					alg, len(sol.Moves), sol.LowerBound)
+			}
+			return sol.Moves
+		})
+	}
+}
+
+func TestSolverMaxDepth(t *testing.T) {
+	_, s := readTestBoard(t)
+	tok := Token{ShapeHexagon, ColourBlue} // 7 moves
+
+	for _, alg := range allAlgorithms {
+		sv := NewSolver(SolveOptions{Algorithm: alg, MaxDepth: 5, TableSize: 1 << 16})
+		sol, err := sv.Solve(context.Background(), s, tok)
+		if err != ErrDepthExceeded {
+			t.Errorf("algorithm %d: expected %v, got %v", alg, ErrDepthExceeded, err)
+		}
+		if sol.Moves != nil || sol.LowerBound != 6 {
+			t.Errorf("algorithm %d: expected lower bound 6, got %d",
+				alg, sol.LowerBound)
+		}
+
+		sv = NewSolver(SolveOptions{Algorithm: alg, MaxDepth: 7, TableSize: 1 << 16})
+		sol, err = sv.Solve(context.Background(), s, tok)
+		if err != nil || len(sol.Moves) != 7 {
+			t.Errorf("algorithm %d: expected 7 moves, got %v, %v",
+				alg, sol.Moves, err)
+		}
+	}
+}
+
+func TestSolverMaxNodes(t *testing.T) {
+	_, s := readTestBoard(t)
+	tok := Token{ShapeHexagon, ColourBlue}
+
+	for _, alg := range allAlgorithms {
+		sv := NewSolver(SolveOptions{Algorithm: alg, MaxNodes: 50})
+		sol, err := sv.Solve(context.Background(), s, tok)
+		if err != ErrBudgetExceeded {
+			t.Errorf("algorithm %d: expected %v, got %v", alg, ErrBudgetExceeded, err)
+		}
+		if sol.Moves != nil || sol.LowerBound < 1 || sol.LowerBound > 7 {
+			t.Errorf("algorithm %d: expected lower bound from 1 to 7, got %d",
+				alg, sol.LowerBound)
+		}
+		if sol.Stats.NodesExpanded > 51 {
+			t.Errorf("algorithm %d: expected at most 51 states expanded, got %d",
+				alg, sol.Stats.NodesExpanded)
+		}
+	}
+}
+
+func TestSolverMaxMemory(t *testing.T) {
+	_, s := readTestBoard(t)
+	tok := Token{ShapeHexagon, ColourBlue}
+
+	for _, alg := range []Algorithm{AlgorithmBFS, AlgorithmAStar,
+		AlgorithmParallelBFS, AlgorithmBidirectional} {
+		sv := NewSolver(SolveOptions{Algorithm: alg, MaxMemory: 1 << 12})
+		if _, err := sv.Solve(context.Background(), s, tok); err != ErrBudgetExceeded {
+			t.Errorf("algorithm %d: expected %v, got %v", alg, ErrBudgetExceeded, err)
+		}
+	}
+
+	// IDA* shrinks its table to fit instead.
+	sv := NewSolver(SolveOptions{
+		Algorithm: AlgorithmIDA,
+		MaxMemory: 1 << 12,
+		TableSize: 1 << 16,
+	})
+	sol, err := sv.Solve(context.Background(), s, tok)
+	if err != nil || len(sol.Moves) != 7 {
+		t.Errorf("expected 7 moves, got %v, %v", sol.Moves, err)
+	}
+}
+
+func TestSolverContext(t *testing.T) {
+	_, s := readTestBoard(t)
+	tok := Token{ShapeTriangle, ColourYellow} // 10 moves
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, alg := range allAlgorithms {
+		sol, err := NewSolver(SolveOptions{Algorithm: alg}).Solve(ctx, s, tok)
+		if err != context.Canceled {
+			t.Errorf("algorithm %d: expected %v, got %v", alg, context.Canceled, err)
+		}
+		if sol == nil || sol.Moves != nil {
+			t.Errorf("algorithm %d: expected no moves", alg)
+		}
+	}
+
+	// The search stops soon after the deadline, part way through.
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	sol, err := NewSolver(SolveOptions{}).Solve(ctx, s, tok)
+	if err != context.DeadlineExceeded {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("expected search to stop within a second, took %v", d)
+	}
+	if sol.LowerBound < 1 || sol.LowerBound > 10 {
+		t.Errorf("expected lower bound from 1 to 10, got %d", sol.LowerBound)
+	}
+}
+
+func TestSolverStats(t *testing.T) {
+	_, s := readTestBoard(t)
+	tok := Token{ShapeHexagon, ColourBlue} // 7 moves
+
+	for _, alg := range allAlgorithms {
+		var progress []SolveStats
+		sv := NewSolver(SolveOptions{
+			Algorithm: alg,
+			TableSize: 1 << 16,
+			Progress: func(stats SolveStats) {
+				progress = append(progress, stats)
+			},
+		})
+		sol, err := sv.Solve(context.Background(), s, tok)
+		if err != nil {
+			t.Fatalf("algorithm %d: expected success, got %v", alg, err)
+		}
+
+		stats := sol.Stats
+		if len(progress) != len(stats.Depths) {
+			t.Errorf("algorithm %d: expected progress for each of %d depths, got %d",
+				alg, len(stats.Depths), len(progress))
+		}
+		for i, p := range progress {
+			if len(p.Depths) != i+1 || p.Depths[i].Depth != stats.Depths[i].Depth {
+				t.Errorf("algorithm %d: expected progress at depth %d, got %v",
+					alg, stats.Depths[i].Depth, p.Depths)
+			}
+		}
+		if n := len(stats.Depths); n == 0 || stats.Depths[n-1].Depth > 7 {
+			t.Errorf("algorithm %d: expected to end by depth 7, got %v",
+				alg, stats.Depths)
+		}
+
+		expanded := 0
+		for _, d := range stats.Depths {
+			expanded += d.NodesExpanded
+		}
+		if expanded == 0 || expanded != stats.NodesExpanded {
+			t.Errorf("algorithm %d: expected %d states expanded over all depths, got %d",
+				alg, stats.NodesExpanded, expanded)
+		}
+		if stats.StatesDeduplicated == 0 {
+			t.Errorf("algorithm %d: expected states to be deduplicated", alg)
+		}
+		if stats.MaxFrontier == 0 {
+			t.Errorf("algorithm %d: expected a frontier", alg)
+		}
+		if stats.Elapsed <= 0 {
+			t.Errorf("algorithm %d: expected time to pass", alg)
+		}
+	}
+
+	// A breadth-first search goes one move at a time.
+	for _, alg := range []Algorithm{AlgorithmBFS, AlgorithmParallelBFS,
+		AlgorithmBidirectional} {
+		sol, _ := NewSolver(SolveOptions{Algorithm: alg}).Solve(
+			context.Background(), s, tok)
+		for i, d := range sol.Stats.Depths {
+			if d.Depth != i+1 {
+				t.Errorf("algorithm %d: expected depth %d, got %d", alg, i+1, d.Depth)
+			}
+		}
+	}
+}
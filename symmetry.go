@@ -0,0 +1,268 @@
+package ricochet
+
+import (
+	"context"
+	"errors"
+)
+
+// Transform is one of the 8 ways of rotating or reflecting a square board onto
+// itself.
+type Transform int
+
+const (
+	TransformIdentity  Transform = 0
+	TransformRotate90  Transform = 1 // clockwise
+	TransformRotate180 Transform = 2
+	TransformRotate270 Transform = 3
+
+	// Reflections: left to right, top to bottom, along the diagonal from the
+	// top left corner, i.e. swapping rows and columns, and along the other
+	// diagonal.
+	TransformFlipX        Transform = 4
+	TransformFlipY        Transform = 5
+	TransformTranspose    Transform = 6
+	TransformAntiDiagonal Transform = 7
+)
+
+var allTransforms = []Transform{TransformIdentity, TransformRotate90,
+	TransformRotate180, TransformRotate270, TransformFlipX, TransformFlipY,
+	TransformTranspose, TransformAntiDiagonal}
+
+// transformDirections[t][d] is where direction `d` points after transform `t`.
+var transformDirections = [][4]Direction{
+	{DirectionNorth, DirectionEast, DirectionSouth, DirectionWest},
+	{DirectionEast, DirectionSouth, DirectionWest, DirectionNorth},
+	{DirectionSouth, DirectionWest, DirectionNorth, DirectionEast},
+	{DirectionWest, DirectionNorth, DirectionEast, DirectionSouth},
+	{DirectionNorth, DirectionWest, DirectionSouth, DirectionEast},
+	{DirectionSouth, DirectionEast, DirectionNorth, DirectionWest},
+	{DirectionWest, DirectionSouth, DirectionEast, DirectionNorth},
+	{DirectionEast, DirectionNorth, DirectionWest, DirectionSouth},
+}
+
+func (t Transform) Valid() bool {
+	return t >= TransformIdentity && t <= TransformAntiDiagonal
+}
+
+// Inverse returns the transform that undoes this one.
+func (t Transform) Inverse() Transform {
+	switch t {
+	case TransformRotate90:
+		return TransformRotate270
+	case TransformRotate270:
+		return TransformRotate90
+	}
+	return t
+}
+
+// Direction returns where `d` points after this transform.
+func (t Transform) Direction(d Direction) Direction {
+	if !t.Valid() || !d.Valid() {
+		return d
+	}
+	return transformDirections[t][d]
+}
+
+// Position returns where `p` ends up after this transform of a board `size`
+// cells wide.
+func (t Transform) Position(p Position, size int) Position {
+	m := size - 1
+	switch t {
+	case TransformRotate90:
+		return Position{m - p.Y, p.X}
+	case TransformRotate180:
+		return Position{m - p.X, m - p.Y}
+	case TransformRotate270:
+		return Position{p.Y, m - p.X}
+	case TransformFlipX:
+		return Position{m - p.X, p.Y}
+	case TransformFlipY:
+		return Position{p.X, m - p.Y}
+	case TransformTranspose:
+		return Position{p.Y, p.X}
+	case TransformAntiDiagonal:
+		return Position{m - p.Y, m - p.X}
+	}
+	return p
+}
+
+// Transform returns a copy of this board rotated or reflected by `t`, with its
+// walls, oob cells and sinks.
+func (b *Board) Transform(t Transform) *Board {
+	nb, _ := NewBoard(b.size)
+	for i, c := range b.cells {
+		q := nb.index(t.Position(b.position(i), b.size))
+		nb.cells[q] = c & cellOOB
+		for _, d := range allDirections {
+			if c&(1<<uint(d)) != 0 {
+				nb.cells[q] |= 1 << uint(t.Direction(d))
+			}
+		}
+	}
+	for i := 0; i < b.size; i++ {
+		nb.updateStops(Position{i, i})
+	}
+	for tok, p := range b.sinks {
+		nb.sinks[tok] = t.Position(p, b.size)
+	}
+	return nb
+}
+
+// TransformMoves returns `moves` on this board as they'd be made after
+// transform `t`.
+func (b *Board) TransformMoves(t Transform, moves []Move) []Move {
+	if moves == nil {
+		return nil
+	}
+	tm := make([]Move, len(moves))
+	for i, m := range moves {
+		tm[i] = Move{m.Robot, t.Position(m.From, b.size), t.Direction(m.Direction),
+			t.Position(m.Position, b.size)}
+	}
+	return tm
+}
+
+// Symmetries returns the transforms that leave the walls and oob cells of this
+// board where they are, starting with the identity. The sinks are ignored,
+// since each token's is different; see `State.Canonical`.
+func (b *Board) Symmetries() []Transform {
+	var sym []Transform
+	for _, t := range allTransforms {
+		if b.symmetric(t) {
+			sym = append(sym, t)
+		}
+	}
+	return sym
+}
+
+// symmetric returns true if robots move around this board the same way after
+// transform `t`.
+func (b *Board) symmetric(t Transform) bool {
+	for i := range b.cells {
+		p := b.position(i)
+		q := t.Position(p, b.size)
+		if b.InBounds(p) != b.InBounds(q) {
+			return false
+		}
+		for _, d := range allDirections {
+			if b.canSlide(p, d) != b.canSlide(q, t.Direction(d)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Transform returns this state with the robots moved by `t`, on the board as
+// transformed by `t`.
+func (s *State) Transform(t Transform) *State {
+	return s.transformOn(s.board.Transform(t), t)
+}
+
+// transformOn returns this state with the robots moved by `t`, on board `b`.
+func (s *State) transformOn(b *Board, t Transform) *State {
+	n := &State{
+		board:  b,
+		robots: append([]Robot(nil), s.robots...),
+		pos:    make([]Position, len(s.pos)),
+	}
+	for i, p := range s.pos {
+		n.pos[i] = t.Position(p, s.board.size)
+	}
+	return n
+}
+
+// Canonical returns the same state as every other state it's equivalent to
+// when solving for `tok`, on the same board, and the transform that takes this
+// state to it. States are equivalent if one of the board's symmetries that
+// leaves the sink for `tok` in place takes one to the other. A solution from
+// the canonical state is transformed back into one from this state by the
+// inverse of the transform.
+func (s *State) Canonical(tok Token) (*State, Transform) {
+	return s.canonical(tok, s.board.Symmetries())
+}
+
+// canonical is `State.Canonical`, given the board's symmetries.
+func (s *State) canonical(tok Token, symmetries []Transform) (*State, Transform) {
+	b := s.board
+	best, bestT := s, TransformIdentity
+	sink, ok := b.sinks[tok]
+	if !ok {
+		return s, TransformIdentity
+	}
+	for _, t := range symmetries {
+		if !t.Position(sink, b.size).Equal(sink) {
+			continue
+		}
+		n := s.transformOn(b, t)
+		if n.before(best) {
+			best, bestT = n, t
+		}
+	}
+	return best, bestT
+}
+
+// before returns true if this state comes before `s2`, by the cells of the
+// robots in order.
+func (s *State) before(s2 *State) bool {
+	for i, p := range s.pos {
+		a, b := s.board.index(p), s2.board.index(s2.pos[i])
+		if a != b {
+			return a < b
+		}
+	}
+	return false
+}
+
+// SolveStates solves for `tok` from each of `starts`, which must all be on
+// this board, like `Board.SolveAll` does for many tokens. Each start is first
+// made canonical, so starts that are equivalent because of the board's
+// symmetries are only solved once, and their solutions are transformed back.
+func (b *Board) SolveStates(ctx context.Context, starts []*State, tok Token, opts SolveOptions) ([]*Solution, error) {
+	for _, s := range starts {
+		if s.board != b {
+			return nil, errors.New("state is for a different board")
+		}
+	}
+
+	// Find the distinct canonical states.
+	var (
+		canonical  []*State
+		transforms = make([]Transform, len(starts))
+		which      = make([]int, len(starts)) // index in `canonical`
+		seen       = make(map[string]int)
+	)
+	symmetries := b.Symmetries()
+	for i, s := range starts {
+		c, t := s.canonical(tok, symmetries)
+		k := c.String()
+		j, ok := seen[k]
+		if !ok {
+			j = len(canonical)
+			seen[k] = j
+			canonical = append(canonical, c)
+		}
+		transforms[i], which[i] = t, j
+	}
+
+	workers := opts.Workers
+	opts.Workers = 1
+	sv := NewSolver(opts)
+	solutions := make([]*Solution, len(canonical))
+	errs := make([]error, len(canonical))
+	forEach(len(canonical), workers, func(i int) {
+		solutions[i], errs[i] = sv.Solve(ctx, canonical[i], tok)
+	})
+
+	all := make([]*Solution, len(starts))
+	var err error
+	for i, j := range which {
+		sol := *solutions[j]
+		sol.Moves = b.TransformMoves(transforms[i].Inverse(), sol.Moves)
+		all[i] = &sol
+		if err == nil {
+			err = errs[j]
+		}
+	}
+	return all, err
+}
@@ -0,0 +1,133 @@
+package ricochet
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func canonicalBytes(b *Board) string {
+	var buf bytes.Buffer
+	b.writeCanonical(&buf)
+	return buf.String()
+}
+
+func TestTransformInverse(t *testing.T) {
+	b, _ := readTestBoard(t)
+	for _, tr := range allTransforms {
+		for _, d := range allDirections {
+			if got := tr.Inverse().Direction(tr.Direction(d)); got != d {
+				t.Errorf("transform %d: expected direction %d back, got %d", tr, d, got)
+			}
+			// Moving one cell moves the same way after the transform.
+			p := Position{3, 5}
+			if got := tr.Position(p.Next(d), 16); !got.Equal(tr.Position(p, 16).Next(tr.Direction(d))) {
+				t.Errorf("transform %d: expected direction %d to match positions", tr, d)
+			}
+		}
+		if got := b.Transform(tr).Transform(tr.Inverse()); canonicalBytes(got) != canonicalBytes(b) {
+			t.Errorf("transform %d: expected the inverse to give the same board", tr)
+		}
+	}
+
+	// Four quarter turns make a whole one.
+	r := b
+	for i := 0; i < 4; i++ {
+		r = r.Transform(TransformRotate90)
+	}
+	if canonicalBytes(r) != canonicalBytes(b) {
+		t.Errorf("expected four rotations to give the same board")
+	}
+}
+
+func TestBoardTransformSolve(t *testing.T) {
+	_, s := readTestBoard(t)
+	for _, tr := range allTransforms {
+		ts := s.Transform(tr)
+		for _, test := range solveTests {
+			if test.Moves > 7 {
+				continue
+			}
+			moves := s.SolveAStar(test.Token)
+			tm := s.board.TransformMoves(tr, moves)
+			if err := ts.board.Verify(ts, test.Token, tm); err != nil {
+				t.Errorf("transform %d: %v: %v", tr, test.Token, err)
+			}
+			if n := len(ts.SolveAStar(test.Token)); n != test.Moves {
+				t.Errorf("transform %d: expected %v in %d moves, got %d", tr,
+					test.Token, test.Moves, n)
+			}
+		}
+	}
+}
+
+func TestBoardSymmetries(t *testing.T) {
+	b, _ := NewBoard(6)
+	if sym := b.Symmetries(); len(sym) != 8 {
+		t.Errorf("expected 8 symmetries of an empty board, got %v", sym)
+	}
+
+	// Walls either side of the middle on the top and bottom edges are only
+	// symmetric left to right and top to bottom.
+	b.AddWall(Position{2, 0}, DirectionEast)
+	b.AddWall(Position{2, 5}, DirectionEast)
+	exp := []Transform{TransformIdentity, TransformRotate180, TransformFlipX,
+		TransformFlipY}
+	if sym := b.Symmetries(); len(sym) != len(exp) {
+		t.Errorf("expected %v, got %v", exp, sym)
+	} else {
+		for i := range exp {
+			if sym[i] != exp[i] {
+				t.Errorf("expected %v, got %v", exp, sym)
+			}
+		}
+	}
+
+	b2, _ := readTestBoard(t)
+	if sym := b2.Symmetries(); len(sym) != 1 {
+		t.Errorf("expected only the identity for the test board, got %v", sym)
+	}
+}
+
+func TestStateCanonical(t *testing.T) {
+	b, _ := NewBoard(5)
+	red := Token{ShapeCircle, ColourRed}
+	b.AddSink(red, Position{1, 1})
+	b.AddWall(Position{1, 1}, DirectionNorth)
+	b.AddWall(Position{1, 1}, DirectionWest)
+
+	// The board is only symmetric along the diagonal, through the sink.
+	var starts []*State
+	for _, p := range []Position{{1, 4}, {4, 1}, {3, 0}, {0, 3}, {2, 4}} {
+		s := b.NewState()
+		s.AddRobot(p, Robot{ColourRed})
+		s.AddRobot(Position{4, 4}, Robot{ColourBlue})
+		starts = append(starts, s)
+	}
+	for i := 0; i < 4; i += 2 {
+		c0, _ := starts[i].Canonical(red)
+		c1, tr := starts[i+1].Canonical(red)
+		if c1.String() != c0.String() {
+			t.Errorf("start %d: expected %v, got %v", i+1, c0, c1)
+		}
+		if starts[i+1].Transform(tr).String() != c1.String() {
+			t.Errorf("start %d: expected transform %d to give %v", i+1, tr, c1)
+		}
+	}
+	if c, _ := starts[4].Canonical(red); c.String() == starts[0].String() {
+		t.Errorf("expected %v to be different", starts[4])
+	}
+
+	sols, err := b.SolveStates(context.Background(), starts, red, SolveOptions{})
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	for i, s := range starts {
+		if err := b.Verify(s, red, sols[i].Moves); err != nil {
+			t.Errorf("start %d: %v", i, err)
+		}
+		if n := len(s.Solve(red)); len(sols[i].Moves) != n {
+			t.Errorf("start %d: expected %d moves, got %d", i, n, len(sols[i].Moves))
+		}
+	}
+}
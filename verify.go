@@ -0,0 +1,75 @@
+package ricochet
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrRobotMissing means there's no such robot where a move starts.
+	ErrRobotMissing = errors.New("robot missing")
+
+	// ErrBlocked means a robot can't move in the given direction at all.
+	ErrBlocked = errors.New("direction blocked")
+
+	// ErrWrongEnd means a robot wouldn't stop where the move says it does.
+	ErrWrongEnd = errors.New("wrong end position")
+
+	// ErrGoalNotReached means all moves are legal but the token isn't claimed.
+	ErrGoalNotReached = errors.New("goal not reached")
+)
+
+// MoveError reports an illegal move in a sequence of moves.
+type MoveError struct {
+	Index int // the 0-indexed position of the move in the sequence
+	Move  Move
+	Err   error
+}
+
+func (e *MoveError) Error() string {
+	return fmt.Sprintf("move %d (%v): %v", e.Index+1, e.Move, e.Err)
+}
+
+// Apply replays `moves` from this state and returns the resulting state. If a
+// move is illegal the error is a `*MoveError` saying which and why.
+func (s *State) Apply(moves []Move) (*State, error) {
+	n := s.Clone()
+	for i, m := range moves {
+		if err := n.apply(m); err != nil {
+			return nil, &MoveError{i, m, err}
+		}
+	}
+	return n, nil
+}
+
+func (s *State) apply(m Move) error {
+	i, ok := s.at(m.From)
+	if !ok || s.robots[i] != m.Robot {
+		return ErrRobotMissing
+	}
+	if !m.Direction.Valid() || !s.CanMove(m.From, m.Direction) {
+		return ErrBlocked
+	}
+	if to := s.Move(m.From, m.Direction); !to.Equal(m.Position) {
+		return ErrWrongEnd
+	}
+	s.pos[i] = m.Position
+	return nil
+}
+
+// Verify checks that `moves` are legal from `start` and end with `tok`
+// claimed. It returns a `*MoveError` for the first illegal move, or
+// `ErrGoalNotReached`.
+func (b *Board) Verify(start *State, tok Token, moves []Move) error {
+	if start.board != b {
+		return errors.New("state is for a different board")
+	}
+	end, err := start.Apply(moves)
+	if err != nil {
+		return err
+	}
+	if !end.Solved(tok) {
+		return ErrGoalNotReached
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package ricochet
+
+import "testing"
+
+type verifyTest struct {
+	Moves []Move
+	Index int   // the index of the illegal move, if any
+	Err   error // the reason
+}
+
+func TestBoardVerify(t *testing.T) {
+	b, _ := NewBoard(10)
+	b.AddWall(Position{1, 1}, DirectionNorth)
+	tok := Token{ShapeCircle, ColourRed}
+	b.AddSink(tok, Position{1, 1})
+	s := b.NewState()
+	red, blue := Robot{ColourRed}, Robot{ColourBlue}
+	s.AddRobot(Position{1, 5}, red)
+	s.AddRobot(Position{9, 1}, blue)
+
+	tests := []verifyTest{
+		{[]Move{{red, Position{1, 5}, DirectionNorth, Position{1, 1}}}, 0, nil},
+		{[]Move{
+			{blue, Position{9, 1}, DirectionSouth, Position{9, 9}},
+			{red, Position{1, 5}, DirectionEast, Position{9, 5}},
+		}, 0, ErrGoalNotReached},
+		{[]Move{
+			{red, Position{1, 5}, DirectionWest, Position{0, 5}},
+			{red, Position{1, 5}, DirectionNorth, Position{1, 1}},
+		}, 1, ErrRobotMissing},
+		{[]Move{{blue, Position{1, 5}, DirectionNorth, Position{1, 1}}}, 0,
+			ErrRobotMissing},
+		{[]Move{
+			{blue, Position{9, 1}, DirectionEast, Position{9, 1}},
+		}, 0, ErrBlocked},
+		{[]Move{
+			{red, Position{1, 5}, DirectionNorth, Position{1, 1}},
+			{red, Position{1, 1}, DirectionNorth, Position{1, 0}},
+		}, 1, ErrBlocked},
+		{[]Move{{red, Position{1, 5}, Direction(4), Position{1, 1}}}, 0,
+			ErrBlocked},
+		{[]Move{{red, Position{1, 5}, DirectionNorth, Position{1, 0}}}, 0,
+			ErrWrongEnd},
+		{nil, 0, ErrGoalNotReached},
+	}
+
+	for i, test := range tests {
+		err := b.Verify(s, tok, test.Moves)
+		if test.Err == nil || test.Err == ErrGoalNotReached {
+			if err != test.Err {
+				t.Errorf("test %d: expected %v, got %v", i, test.Err, err)
+			}
+			continue
+		}
+		me, ok := err.(*MoveError)
+		if !ok {
+			t.Errorf("test %d: expected move error, got %v", i, err)
+			continue
+		}
+		if me.Index != test.Index || me.Err != test.Err {
+			t.Errorf("test %d: expected move %d: %v, got move %d: %v",
+				i, test.Index, test.Err, me.Index, me.Err)
+		}
+	}
+
+	b2, _ := NewBoard(10)
+	if err := b2.Verify(s, tok, tests[0].Moves); err == nil {
+		t.Errorf("expected error")
+	}
+}
+
+func TestStateApply(t *testing.T) {
+	b, _ := NewBoard(10)
+	s := b.NewState()
+	red := Robot{ColourRed}
+	s.AddRobot(Position{1, 5}, red)
+
+	moves := []Move{
+		{red, Position{1, 5}, DirectionNorth, Position{1, 0}},
+		{red, Position{1, 0}, DirectionEast, Position{9, 0}},
+	}
+	n, err := s.Apply(moves)
+	if err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	if p, _ := n.Find(ColourRed); !p.Equal(Position{9, 0}) {
+		t.Errorf("expected robot at %v, got %v", Position{9, 0}, p)
+	}
+	if p, _ := s.Find(ColourRed); !p.Equal(Position{1, 5}) {
+		t.Errorf("expected original state to be unchanged")
+	}
+}
+
+func TestBoardVerifySolve(t *testing.T) {
+	b, s := readTestBoard(t)
+
+	tok := Token{ShapeHexagon, ColourYellow}
+	if err := b.Verify(s, tok, s.Solve(tok)); err != nil {
+		t.Errorf("expected success, got %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+package ricochet
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// WriteBoard writes board `b`, and the robots in `s` unless it's nil, in the
+// syntax `ReadBoard` reads. The same board is always written the same way:
+// oob cells, walls and sinks in order of position, by row and then column,
+// then robots by colour. Each wall is written once, from the cell to its west
+// or north if that's in bounds, however it was added.
+func WriteBoard(w io.Writer, b *Board, s *State) error {
+	if s != nil && s.board != b {
+		return errors.New("state is for a different board")
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "BOARD %d\n", b.size)
+	for i := range b.cells {
+		if p := b.position(i); !b.InBounds(p) {
+			fmt.Fprintf(bw, "OOB %d,%d\n", p.X, p.Y)
+		}
+	}
+
+	walls := make(map[int]bool) // by cell index * 4 + direction
+	for i := range b.cells {
+		p := b.position(i)
+		for _, d := range allDirections {
+			if !b.wall(p, d) {
+				continue
+			}
+			wp, wd := p, d
+			q := p.Next(d)
+			switch {
+			case !b.InBounds(p) && !b.InBounds(q):
+				// Robots can't get to either side.
+				continue
+			case !b.InBounds(p),
+				b.InBounds(q) && (d == DirectionNorth || d == DirectionWest):
+				wp, wd = q, d.Flip()
+			}
+			walls[b.index(wp)*4+int(wd)] = true
+		}
+	}
+	wl := make([]int, 0, len(walls))
+	for k := range walls {
+		wl = append(wl, k)
+	}
+	sort.Ints(wl)
+	for _, k := range wl {
+		p := b.position(k / 4)
+		fmt.Fprintf(bw, "WALL %d,%d %d\n", p.X, p.Y, k%4)
+	}
+
+	tokens := b.tokens()
+	sort.Sort(tokensByPosition{b, tokens})
+	for _, tok := range tokens {
+		p := b.sinks[tok]
+		fmt.Fprintf(bw, "SINK %d,%d %d %d\n", p.X, p.Y, tok.Colour, tok.Shape)
+	}
+
+	if s != nil {
+		for i, r := range s.robots {
+			fmt.Fprintf(bw, "ROBOT %d,%d %d\n", s.pos[i].X, s.pos[i].Y, r.Colour)
+		}
+	}
+	return bw.Flush()
+}
+
+// tokensByPosition sorts tokens by the position of their sinks on a board.
+type tokensByPosition struct {
+	board  *Board
+	tokens []Token
+}
+
+func (tp tokensByPosition) Len() int { return len(tp.tokens) }
+
+func (tp tokensByPosition) Swap(i, j int) {
+	tp.tokens[i], tp.tokens[j] = tp.tokens[j], tp.tokens[i]
+}
+
+func (tp tokensByPosition) Less(i, j int) bool {
+	b := tp.board
+	return b.index(b.sinks[tp.tokens[i]]) < b.index(b.sinks[tp.tokens[j]])
+}
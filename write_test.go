@@ -0,0 +1,134 @@
+package ricochet
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// roundTrip writes a board and state and reads them back.
+func roundTrip(t *testing.T, b *Board, s *State) (string, *Board, *State) {
+	var buf bytes.Buffer
+	if err := WriteBoard(&buf, b, s); err != nil {
+		t.Fatalf("expected success, got %v", err)
+	}
+	b2, s2, err := ReadBoard(bufio.NewReader(strings.NewReader(buf.String())))
+	if err != nil {
+		t.Fatalf("expected to read back %q, got %v", buf.String(), err)
+	}
+	return buf.String(), b2, s2
+}
+
+func TestWriteBoard(t *testing.T) {
+	b, s := readTestBoard(t)
+	text, b2, s2 := roundTrip(t, b, s)
+	if canonicalBytes(b2) != canonicalBytes(b) || s2.String() != s.String() {
+		t.Errorf("expected the same board, got %q", text)
+	}
+
+	if text2, _, _ := roundTrip(t, b2, s2); text2 != text {
+		t.Errorf("expected the same text again, got %q", text2)
+	}
+
+	// Walls are written once, from the west or north, unless that side is
+	// oob or off the board.
+	b, _ = NewBoard(4)
+	b.AddWall(Position{2, 1}, DirectionWest)
+	b.AddWall(Position{1, 1}, DirectionEast)
+	b.AddWall(Position{1, 3}, DirectionNorth)
+	b.AddWall(Position{3, 0}, DirectionNorth)
+	b.AddWall(Position{0, 1}, DirectionEast)
+	b.SetOOB(Position{0, 1})
+	b.AddSink(Token{ShapeHexagon, ColourGreen}, Position{3, 2})
+	b.AddSink(Token{ShapeCircle, ColourRed}, Position{1, 3})
+	s = b.NewState()
+	s.AddRobot(Position{0, 0}, Robot{ColourSilver})
+	s.AddRobot(Position{3, 3}, Robot{ColourYellow})
+	exp := `BOARD 4
+OOB 0,1
+WALL 3,0 0
+WALL 1,1 1
+WALL 1,1 3
+WALL 1,2 2
+SINK 3,2 2 3
+SINK 1,3 3 0
+ROBOT 3,3 1
+ROBOT 0,0 10
+`
+	if text, _, _ := roundTrip(t, b, s); text != exp {
+		t.Errorf("expected %q, got %q", exp, text)
+	}
+}
+
+func TestWriteBoardRoundTrip(t *testing.T) {
+	var boards []*Board
+	for seed := int64(0); seed < 5; seed++ {
+		for _, size := range []int{16, 21} {
+			b, _ := RandomBoard(rand.New(rand.NewSource(seed)), size)
+			boards = append(boards, b)
+		}
+	}
+	tb, _ := readTestBoard(t)
+	for _, tr := range allTransforms {
+		boards = append(boards, tb.Transform(tr))
+	}
+
+	// Walls added from both sides and next to oob cells, the multi-colour
+	// token and a silver robot.
+	b, _ := NewBoard(5)
+	b.AddWall(Position{1, 1}, DirectionEast)
+	b.AddWall(Position{2, 1}, DirectionWest)
+	b.AddWall(Position{3, 3}, DirectionNorth)
+	b.AddWall(Position{2, 2}, DirectionSouth)
+	b.AddWall(Position{4, 4}, DirectionSouth)
+	b.AddWall(Position{2, 4}, DirectionWest)
+	b.SetOOB(Position{1, 4})
+	b.SetOOB(Position{0, 4})
+	b.AddSink(TokenVortex, Position{3, 3})
+	boards = append(boards, b)
+
+	for i, b := range boards {
+		s, err := RandomState(rand.New(rand.NewSource(int64(i))), b,
+			[]Colour{ColourBlue, ColourRed, ColourSilver})
+		if err != nil {
+			t.Fatalf("board %d: expected success, got %v", i, err)
+		}
+		text, b2, s2 := roundTrip(t, b, s)
+		if canonicalBytes(b2) != canonicalBytes(b) {
+			t.Errorf("board %d: expected the same board, got %q", i, text)
+		}
+		if s2.String() != s.String() {
+			t.Errorf("board %d: expected %v, got %v", i, s, s2)
+		}
+		if text2, _, _ := roundTrip(t, b2, s2); text2 != text {
+			t.Errorf("board %d: expected the same text again, got %q", i, text2)
+		}
+	}
+
+	// Without robots.
+	text, b2, s2 := roundTrip(t, b, nil)
+	if canonicalBytes(b2) != canonicalBytes(b) || len(s2.robots) != 0 ||
+		strings.Contains(text, "ROBOT") {
+		t.Errorf("expected no robots, got %q", text)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
+
+func TestWriteBoardErrors(t *testing.T) {
+	b, s := readTestBoard(t)
+	if err := WriteBoard(failingWriter{}, b, s); err == nil {
+		t.Errorf("expected an error writing")
+	}
+	b2, _ := readTestBoard(t)
+	if err := WriteBoard(&bytes.Buffer{}, b2, s); err == nil {
+		t.Errorf("expected an error for a state on a different board")
+	}
+}